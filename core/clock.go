@@ -0,0 +1,16 @@
+package core
+
+import "time"
+
+// Clock is the source of the current time for loggers and handlers.
+// Replacing it makes timestamps and time-based rotation deterministic in
+// tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock backed by time.Now (default)
+type SystemClock struct{}
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time { return time.Now() }
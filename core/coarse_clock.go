@@ -4,35 +4,178 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
-	"unsafe"
 )
 
+// DefaultCoarseResolution is the default tick interval of a CoarseClock
+const DefaultCoarseResolution = 500 * time.Microsecond
+
+// CoarseClockConfig holds configuration for a CoarseClock
+type CoarseClockConfig struct {
+	// Resolution is the interval at which the cached time is refreshed
+	// (default: DefaultCoarseResolution)
+	Resolution time.Duration
+	// IdleTimeout suspends ticking when Now was not called for this long;
+	// the next call resumes it (0 = never suspend)
+	IdleTimeout time.Duration
+}
+
+// CoarseClock is a Clock that caches time.Now() in a background goroutine,
+// trading timestamp granularity for a cheaper Now. The goroutine runs only
+// between Start and the matching Stop; calls are reference counted so
+// several loggers can share one clock. While stopped or suspended, Now
+// falls back to time.Now().
+type CoarseClock struct {
+	resolution  time.Duration
+	idleTimeout time.Duration
+
+	now  atomic.Pointer[time.Time] // nil while stopped or suspended
+	used atomic.Bool               // Now was called since the last idle check
+	wake chan struct{}
+
+	mu   sync.Mutex
+	refs int
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewCoarseClock creates a stopped coarse clock
+func NewCoarseClock(cfg CoarseClockConfig) *CoarseClock {
+	if cfg.Resolution <= 0 {
+		cfg.Resolution = DefaultCoarseResolution
+	}
+	return &CoarseClock{
+		resolution:  cfg.Resolution,
+		idleTimeout: cfg.IdleTimeout,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Now returns the most recently cached time, or time.Now() while the
+// clock is stopped or suspended.
+func (c *CoarseClock) Now() time.Time {
+	if t := c.now.Load(); t != nil {
+		if c.idleTimeout > 0 && !c.used.Load() {
+			c.used.Store(true)
+		}
+		return *t
+	}
+	if c.idleTimeout > 0 {
+		select {
+		case c.wake <- struct{}{}:
+		default:
+		}
+	}
+	return time.Now()
+}
+
+// Start takes a reference on the clock, starting the background goroutine
+// if this is the first one.
+func (c *CoarseClock) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refs++
+	if c.refs > 1 {
+		return
+	}
+	t := time.Now()
+	c.now.Store(&t)
+	c.used.Store(true)
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go c.run(c.stop, c.done)
+}
+
+// Stop releases a reference taken by Start. The background goroutine
+// exits, and Stop waits for it, when the last reference is released.
+// Extra calls are ignored.
+func (c *CoarseClock) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refs == 0 {
+		return
+	}
+	c.refs--
+	if c.refs > 0 {
+		return
+	}
+	close(c.stop)
+	<-c.done
+	c.now.Store(nil)
+}
+
+// Running reports whether the clock is ticking, i.e. started and not
+// suspended.
+func (c *CoarseClock) Running() bool {
+	return c.now.Load() != nil
+}
+
+// run refreshes the cached time until stop is closed.
+func (c *CoarseClock) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(c.resolution)
+	defer ticker.Stop()
+
+	var idle <-chan time.Time
+	if c.idleTimeout > 0 {
+		idleTicker := time.NewTicker(c.idleTimeout)
+		defer idleTicker.Stop()
+		idle = idleTicker.C
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			t := time.Now()
+			c.now.Store(&t)
+		case <-idle:
+			if c.used.Swap(false) {
+				continue
+			}
+			// Nothing read the clock for a whole IdleTimeout: suspend until
+			// Now is called again
+			select {
+			case <-c.wake:
+			default:
+			}
+			c.now.Store(nil)
+			ticker.Stop()
+			select {
+			case <-c.wake:
+			case <-stop:
+				return
+			}
+			t := time.Now()
+			c.now.Store(&t)
+			c.used.Store(true)
+			ticker.Reset(c.resolution)
+		case <-stop:
+			return
+		}
+	}
+}
+
 var (
-	coarseClockOnce sync.Once
-	coarseNow       unsafe.Pointer // *time.Time
+	defaultCoarseClock = NewCoarseClock(CoarseClockConfig{})
+	coarseClockOnce    sync.Once
 )
 
-// StartCoarseClock starts the background goroutine that caches
-// time.Now() every 500µs. It is safe to call multiple times; the
-// goroutine is started exactly once. The goroutine runs for the
-// lifetime of the process; this is intentional because logging
-// typically spans the entire application lifecycle.
+// DefaultCoarseClock returns the process-wide coarse clock shared by
+// loggers built with WithCoarseClock.
+func DefaultCoarseClock() *CoarseClock {
+	return defaultCoarseClock
+}
+
+// StartCoarseClock starts the default coarse clock and keeps it running
+// for the lifetime of the process. It is safe to call multiple times.
+// Prefer Start and Stop on DefaultCoarseClock or an own CoarseClock, which
+// let the goroutine exit.
 func StartCoarseClock() {
-	coarseClockOnce.Do(func() {
-		t := time.Now()
-		atomic.StorePointer(&coarseNow, unsafe.Pointer(&t))
-		go func() {
-			ticker := time.NewTicker(500 * time.Microsecond)
-			for range ticker.C {
-				t := time.Now()
-				atomic.StorePointer(&coarseNow, unsafe.Pointer(&t))
-			}
-		}()
-	})
+	coarseClockOnce.Do(defaultCoarseClock.Start)
 }
 
-// CoarseNow returns the most recently cached time.Time value.
-// StartCoarseClock must have been called before using CoarseNow.
+// CoarseNow returns the time of the default coarse clock.
 func CoarseNow() time.Time {
-	return *(*time.Time)(atomic.LoadPointer(&coarseNow))
+	return defaultCoarseClock.Now()
 }
@@ -35,3 +35,69 @@ func TestStartCoarseClockIdempotent(t *testing.T) {
 		t.Error("CoarseNow() returned zero time after multiple StartCoarseClock calls")
 	}
 }
+
+func TestCoarseClock_RefCount(t *testing.T) {
+	c := NewCoarseClock(CoarseClockConfig{Resolution: time.Millisecond})
+	if c.Running() {
+		t.Fatal("Expected new clock to be stopped")
+	}
+
+	c.Start()
+	c.Start()
+	c.Stop()
+	if !c.Running() {
+		t.Error("Expected clock to keep running while a reference is held")
+	}
+	c.Stop()
+	if c.Running() {
+		t.Error("Expected clock to stop after the last reference")
+	}
+	c.Stop() // Extra Stop is ignored
+
+	// A stopped clock reports the precise time
+	before := time.Now()
+	if got := c.Now(); got.Before(before) {
+		t.Errorf("Now() = %v on stopped clock, want >= %v", got, before)
+	}
+
+	c.Start()
+	defer c.Stop()
+	if !c.Running() {
+		t.Error("Expected clock to restart")
+	}
+}
+
+func TestCoarseClock_Resolution(t *testing.T) {
+	c := NewCoarseClock(CoarseClockConfig{Resolution: time.Hour})
+	c.Start()
+	defer c.Stop()
+
+	first := c.Now()
+	time.Sleep(2 * time.Millisecond)
+	if got := c.Now(); !got.Equal(first) {
+		t.Errorf("Expected cached time %v within resolution, got %v", first, got)
+	}
+}
+
+func TestCoarseClock_IdleSuspend(t *testing.T) {
+	c := NewCoarseClock(CoarseClockConfig{Resolution: time.Millisecond, IdleTimeout: 5 * time.Millisecond})
+	c.Start()
+	defer c.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for c.Running() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected idle clock to suspend")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Now() // Resumes ticking
+	deadline = time.Now().Add(time.Second)
+	for !c.Running() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected clock to resume after Now")
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
@@ -31,6 +31,10 @@ type Field struct {
 	Any     interface{}
 }
 
+// LoggerNameKey is the key of the field that carries a logger's name,
+// set with Builder.WithName or Logger.Named
+const LoggerNameKey = "logger"
+
 // StringValue returns the string representation of a field's value
 func (f Field) StringValue() string {
 	switch f.Type {
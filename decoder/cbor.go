@@ -0,0 +1,243 @@
+package decoder
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"time"
+)
+
+// cborReader reads CBOR values
+type cborReader struct {
+	r *bufio.Reader
+}
+
+// errBreak is returned by readValue for the break code ending an
+// indefinite-length item
+var errBreak = fmt.Errorf("%w: unexpected CBOR break", ErrFormat)
+
+// head reads an initial byte and its argument. info is the additional
+// information; 31 marks an indefinite length or, for major type 7, a break.
+func (c *cborReader) head() (major, info byte, arg uint64, err error) {
+	b, err := c.r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		raw, err := readN(c.r, 1<<(info-24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for _, x := range raw {
+			arg = arg<<8 | uint64(x)
+		}
+		return major, info, arg, nil
+	case info == 31:
+		return major, info, 0, nil
+	}
+	return 0, 0, 0, fmt.Errorf("%w: reserved CBOR additional information %d", ErrFormat, info)
+}
+
+func (c *cborReader) readValue(depth int) (any, error) {
+	major, info, arg, err := c.head()
+	if err != nil {
+		return nil, err
+	}
+	indefinite := info == 31
+	switch major {
+	case 0:
+		if arg <= math.MaxInt64 {
+			return int64(arg), nil
+		}
+		return arg, nil
+	case 1:
+		if arg <= math.MaxInt64 {
+			return -1 - int64(arg), nil
+		}
+		return -1 - float64(arg), nil
+	case 2, 3:
+		var b []byte
+		if indefinite {
+			b, err = c.readChunks(major)
+		} else {
+			b, err = readN(c.r, arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if major == 2 {
+			return b, nil
+		}
+		return string(b), nil
+	case 4:
+		return c.readArray(arg, indefinite, depth)
+	case 5:
+		return c.readMap(arg, indefinite, depth)
+	case 6:
+		return c.readTag(arg, depth)
+	}
+
+	// Major type 7: simple values and floats
+	switch {
+	case indefinite:
+		return nil, errBreak
+	case info == 25:
+		return halfToFloat(uint16(arg)), nil
+	case info == 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case info == 27:
+		return math.Float64frombits(arg), nil
+	case arg == 20:
+		return false, nil
+	case arg == 21:
+		return true, nil
+	case arg == 22, arg == 23:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported CBOR simple value %d", ErrFormat, arg)
+}
+
+// cborDurationTag is the RFC 9581 duration tag
+const cborDurationTag = 1002
+
+// readTag reads a tagged value; date/time tags become time.Time and
+// duration tags time.Duration
+func (c *cborReader) readTag(tag uint64, depth int) (any, error) {
+	v, err := c.readValue(depth)
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case 0:
+		if s, ok := v.(string); ok {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			return t, nil
+		}
+	case 1:
+		switch n := v.(type) {
+		case int64:
+			return time.Unix(n, 0), nil
+		case float64:
+			sec, frac := math.Modf(n)
+			return time.Unix(int64(sec), int64(frac*1e9)), nil
+		}
+	case cborDurationTag:
+		if m, ok := v.([]pair); ok && len(m) == 1 {
+			switch n := m[0].value.(type) {
+			case int64:
+				switch m[0].key {
+				case "1":
+					return time.Duration(n) * time.Second, nil
+				case "-9":
+					return time.Duration(n), nil
+				}
+			case float64:
+				if m[0].key == "1" {
+					return time.Duration(n * float64(time.Second)), nil
+				}
+			}
+		}
+	}
+	return v, nil
+}
+
+// readChunks reads an indefinite-length byte or text string
+func (c *cborReader) readChunks(major byte) ([]byte, error) {
+	var b []byte
+	for {
+		m, info, n, err := c.head()
+		if err != nil {
+			return nil, err
+		}
+		if m == 7 && info == 31 {
+			return b, nil
+		}
+		if m != major || info == 31 {
+			return nil, fmt.Errorf("%w: invalid CBOR string chunk", ErrFormat)
+		}
+		if uint64(len(b))+n > maxLength {
+			return nil, fmt.Errorf("%w: length exceeds limit", ErrFormat)
+		}
+		chunk, err := readN(c.r, n)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+}
+
+func (c *cborReader) readArray(n uint64, indefinite bool, depth int) (any, error) {
+	if err := checkItems(n, depth); err != nil {
+		return nil, err
+	}
+	var a []any
+	for i := uint64(0); indefinite || i < n; i++ {
+		v, err := c.readValue(depth + 1)
+		if err == errBreak && indefinite {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if i >= maxItems {
+			return nil, fmt.Errorf("%w: elements exceed limit", ErrFormat)
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (c *cborReader) readMap(n uint64, indefinite bool, depth int) (any, error) {
+	if err := checkItems(n, depth); err != nil {
+		return nil, err
+	}
+	var ps []pair
+	for i := uint64(0); indefinite || i < n; i++ {
+		k, err := c.readValue(depth + 1)
+		if err == errBreak && indefinite {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if i >= maxItems {
+			return nil, fmt.Errorf("%w: elements exceed limit", ErrFormat)
+		}
+		v, err := c.readValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, pair{key: mapKey(k), value: v})
+	}
+	return ps, nil
+}
+
+// halfToFloat converts an IEEE 754 half-precision float
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var v float64
+	switch exp {
+	case 0:
+		v = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		v = -v
+	}
+	return v
+}
@@ -0,0 +1,211 @@
+package decoder
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// ErrFormat is returned for input that is not a valid entry stream
+var ErrFormat = errors.New("decoder: malformed input")
+
+// Limits that keep corrupt input from exhausting memory or the stack
+const (
+	maxLength = 64 << 20 // bytes in a string
+	maxItems  = 1 << 20  // elements in an array or map
+	maxDepth  = 64       // nesting of arrays and maps
+)
+
+// pair is a map entry; maps keep their order so fields do too
+type pair struct {
+	key   string
+	value any
+}
+
+// valueReader reads one value of a binary format
+type valueReader interface {
+	readValue(depth int) (any, error)
+}
+
+// Decoder reads entries from a MessagePack or CBOR stream
+type Decoder struct {
+	r      *bufio.Reader
+	values valueReader
+}
+
+// NewMsgpackDecoder returns a decoder for a formatter.MsgpackFormatter stream
+func NewMsgpackDecoder(r io.Reader) *Decoder {
+	br := bufio.NewReader(r)
+	return &Decoder{r: br, values: &msgpackReader{r: br}}
+}
+
+// NewCBORDecoder returns a decoder for a formatter.CBORFormatter stream
+func NewCBORDecoder(r io.Reader) *Decoder {
+	br := bufio.NewReader(r)
+	return &Decoder{r: br, values: &cborReader{r: br}}
+}
+
+// Decode reads the next entry. It returns io.EOF at the end of the stream
+// and io.ErrUnexpectedEOF when the stream ends inside an entry.
+func (d *Decoder) Decode() (*core.Entry, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	v, err := d.values.readValue(0)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	m, ok := v.([]pair)
+	if !ok {
+		return nil, fmt.Errorf("%w: entry is a %T, not a map", ErrFormat, v)
+	}
+	return toEntry(m), nil
+}
+
+// toEntry maps the keys written by the binary formatters onto an Entry
+func toEntry(m []pair) *core.Entry {
+	entry := &core.Entry{}
+	var hasTime, hasLevel, hasMessage, hasCaller bool
+	for _, p := range m {
+		switch {
+		case p.key == "time" && !hasTime:
+			if t, ok := p.value.(time.Time); ok {
+				entry.Time, hasTime = t, true
+				continue
+			}
+		case p.key == "level" && !hasLevel:
+			if s, ok := p.value.(string); ok {
+				if level, ok := parseLevel(s); ok {
+					entry.Level, hasLevel = level, true
+					continue
+				}
+			}
+		case p.key == "message" && !hasMessage:
+			if s, ok := p.value.(string); ok {
+				entry.Message, hasMessage = s, true
+				continue
+			}
+		case p.key == "caller" && !hasCaller:
+			if c, ok := p.value.([]pair); ok {
+				entry.Caller, hasCaller = toCaller(c), true
+				continue
+			}
+		}
+		entry.Fields = append(entry.Fields, toField(p.key, p.value))
+	}
+	return entry
+}
+
+// parseLevel returns the level named s
+func parseLevel(s string) (core.Level, bool) {
+	for level := core.DebugLevel; level <= core.PanicLevel; level++ {
+		if level.String() == s {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// toCaller reads the file, line and function of a caller map
+func toCaller(m []pair) core.CallerInfo {
+	caller := core.CallerInfo{Defined: true}
+	for _, p := range m {
+		switch p.key {
+		case "file":
+			caller.ShortFile, _ = p.value.(string)
+			caller.File = caller.ShortFile
+		case "line":
+			line, _ := p.value.(int64)
+			caller.Line = int(line)
+		case "function":
+			caller.Function, _ = p.value.(string)
+		}
+	}
+	return caller
+}
+
+// toField converts a decoded value to a field of the closest type
+func toField(key string, v any) core.Field {
+	switch v := v.(type) {
+	case string:
+		return core.Field{Key: key, Type: core.StringType, Str: v}
+	case int64:
+		return core.Field{Key: key, Type: core.Int64Type, Int64: v}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return core.Field{Key: key, Type: core.Int64Type, Int64: int64(v)}
+		}
+	case float64:
+		return core.Field{Key: key, Type: core.Float64Type, Float64: v}
+	case bool:
+		f := core.Field{Key: key, Type: core.BoolType}
+		if v {
+			f.Int64 = 1
+		}
+		return f
+	case time.Time:
+		return core.Field{Key: key, Type: core.TimeType, Int64: v.UnixNano()}
+	case time.Duration:
+		return core.Field{Key: key, Type: core.DurationType, Int64: int64(v)}
+	}
+	return core.Field{Key: key, Type: core.AnyType, Any: plain(v)}
+}
+
+// plain converts ordered maps to map[string]any for AnyType values
+func plain(v any) any {
+	switch v := v.(type) {
+	case []pair:
+		m := make(map[string]any, len(v))
+		for _, p := range v {
+			m[p.key] = plain(p.value)
+		}
+		return m
+	case []any:
+		for i := range v {
+			v[i] = plain(v[i])
+		}
+	}
+	return v
+}
+
+// mapKey converts a decoded map key to a string
+func mapKey(k any) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	return fmt.Sprint(k)
+}
+
+// readN reads n bytes, rejecting lengths over maxLength
+func readN(r *bufio.Reader, n uint64) ([]byte, error) {
+	if n > maxLength {
+		return nil, fmt.Errorf("%w: length %d exceeds limit", ErrFormat, n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// checkItems rejects collections over maxItems or nested over maxDepth
+func checkItems(n uint64, depth int) error {
+	if n > maxItems {
+		return fmt.Errorf("%w: %d elements exceed limit", ErrFormat, n)
+	}
+	if depth >= maxDepth {
+		return fmt.Errorf("%w: nesting exceeds %d levels", ErrFormat, maxDepth)
+	}
+	return nil
+}
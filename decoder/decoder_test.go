@@ -0,0 +1,169 @@
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/formatter"
+)
+
+func TestDecoder_RoundTrip(t *testing.T) {
+	ts := time.Date(2026, 2, 18, 13, 4, 5, 123456789, time.UTC)
+	entries := []*core.Entry{
+		{
+			Time:    ts,
+			Level:   core.WarnLevel,
+			Message: "disk almost full",
+			Fields: []core.Field{
+				{Key: "path", Type: core.StringType, Str: "/var/lib/data"},
+				{Key: "free", Type: core.Int64Type, Int64: -1 << 40},
+				{Key: "ratio", Type: core.Float64Type, Float64: 0.03},
+				{Key: "critical", Type: core.BoolType, Int64: 1},
+				{Key: "checked", Type: core.TimeType, Int64: ts.Add(-time.Minute).UnixNano()},
+				{Key: "took", Type: core.DurationType, Int64: int64(1500 * time.Millisecond)},
+				{Key: "err", Type: core.ErrorType, Str: "ENOSPC"},
+				{Key: "small", Type: core.IntType, Int64: 7},
+				{Key: "mounts", Type: core.AnyType, Any: map[string]any{"data": []any{"sda1", 2.5}}},
+				{Key: "quota", Type: core.AnyType, Any: nil},
+			},
+			Caller: core.CallerInfo{ShortFile: "disk.go", Line: 300, Function: "main.check", Defined: true},
+		},
+		{
+			Time:    time.Date(1950, 1, 1, 0, 0, 0, 5, time.UTC), // Before the epoch
+			Level:   core.InfoLevel,
+			Message: string(bytes.Repeat([]byte("x"), 300)),
+		},
+	}
+	cfg := formatter.Config{IncludeCaller: true}
+	tests := []struct {
+		name string
+		f    formatter.BufferFormatter
+		dec  func(io.Reader) *Decoder
+	}{
+		{"msgpack", formatter.NewMsgpackFormatter(cfg), NewMsgpackDecoder},
+		{"cbor", formatter.NewCBORFormatter(cfg), NewCBORDecoder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			for _, e := range entries {
+				tt.f.FormatEntry(e, &buf)
+			}
+			data := buf.Bytes()
+			dec := tt.dec(bytes.NewReader(data))
+			for i, want := range entries {
+				got, err := dec.Decode()
+				if err != nil {
+					t.Fatalf("entry %d: %v", i, err)
+				}
+				if !got.Time.Equal(want.Time) || got.Level != want.Level || got.Message != want.Message {
+					t.Errorf("entry %d: got %v %s %.20q, want %v %s %.20q",
+						i, got.Time, got.Level, got.Message, want.Time, want.Level, want.Message)
+				}
+				if want.Caller.Defined && (got.Caller.ShortFile != "disk.go" || got.Caller.Line != 300 || got.Caller.Function != "main.check") {
+					t.Errorf("entry %d: caller = %+v", i, got.Caller)
+				}
+				if len(got.Fields) != len(want.Fields) {
+					t.Fatalf("entry %d: %d fields, want %d", i, len(got.Fields), len(want.Fields))
+				}
+				for j, w := range want.Fields {
+					if got.Fields[j].Key != w.Key || got.Fields[j].StringValue() != w.StringValue() {
+						t.Errorf("field %s = %s, want %s", w.Key, got.Fields[j].StringValue(), w.StringValue())
+					}
+					// Errors and ints have no binary type of their own
+					wantType := w.Type
+					switch w.Type {
+					case core.ErrorType:
+						wantType = core.StringType
+					case core.IntType:
+						wantType = core.Int64Type
+					}
+					if got.Fields[j].Type != wantType {
+						t.Errorf("field %s has type %d, want %d", w.Key, got.Fields[j].Type, wantType)
+					}
+				}
+				if len(want.Fields) == 0 {
+					continue
+				}
+				mounts, _ := got.Fields[8].Any.(map[string]any)
+				if disks, _ := mounts["data"].([]any); len(disks) != 2 || disks[0] != "sda1" || disks[1] != 2.5 {
+					t.Errorf("mounts = %#v, want a native map", got.Fields[8].Any)
+				}
+				if quota := got.Fields[9]; quota.Type != core.AnyType || quota.Any != nil {
+					t.Errorf("quota = %+v, want nil", quota)
+				}
+			}
+			if _, err := dec.Decode(); err != io.EOF {
+				t.Errorf("Expected io.EOF at end of stream, got %v", err)
+			}
+
+			// A stream cut inside an entry is an unexpected EOF
+			dec = tt.dec(bytes.NewReader(data[:len(data)/2]))
+			dec.Decode()
+			if _, err := dec.Decode(); err != io.ErrUnexpectedEOF {
+				t.Errorf("Expected io.ErrUnexpectedEOF for a truncated stream, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoder_Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		dec  *Decoder
+	}{
+		{"msgpack not a map", NewMsgpackDecoder(bytes.NewReader([]byte{0x01}))},
+		{"msgpack invalid type", NewMsgpackDecoder(bytes.NewReader([]byte{0x81, 0xc1}))},
+		{"msgpack huge string", NewMsgpackDecoder(bytes.NewReader([]byte{0x81, 0xdb, 0xff, 0xff, 0xff, 0xff}))},
+		{"cbor not a map", NewCBORDecoder(bytes.NewReader([]byte{0x61, 'x'}))},
+		{"cbor stray break", NewCBORDecoder(bytes.NewReader([]byte{0xa1, 0xff}))},
+		{"cbor deep nesting", NewCBORDecoder(bytes.NewReader(bytes.Repeat([]byte{0x81}, 100)))},
+	}
+	for _, tt := range tests {
+		if _, err := tt.dec.Decode(); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: error = %v, want ErrFormat", tt.name, err)
+		}
+	}
+}
+
+func TestDecoder_CBORForeignEncodings(t *testing.T) {
+	// Indefinite-length map {"level": "INFO", "half": 1.5 (half float),
+	// "when": 1(1700000000), "tags": ["a"], "wait": 1002({1: 2})} as
+	// other CBOR encoders write it
+	data := []byte{
+		0xbf,
+		0x65, 'l', 'e', 'v', 'e', 'l', 0x64, 'I', 'N', 'F', 'O',
+		0x64, 'h', 'a', 'l', 'f', 0xf9, 0x3e, 0x00,
+		0x64, 'w', 'h', 'e', 'n', 0xc1, 0x1a, 0x65, 0x53, 0xf1, 0x00,
+		0x64, 't', 'a', 'g', 's', 0x9f, 0x61, 'a', 0xff,
+		0x64, 'w', 'a', 'i', 't', 0xd9, 0x03, 0xea, 0xa1, 0x01, 0x02,
+		0xff,
+	}
+	entry, err := NewCBORDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Level != core.InfoLevel || len(entry.Fields) != 4 {
+		t.Fatalf("Unexpected entry %+v", entry)
+	}
+	if f := entry.Fields[0]; f.Type != core.Float64Type || f.Float64 != 1.5 {
+		t.Errorf("half = %+v, want 1.5", f)
+	}
+	if f := entry.Fields[1]; f.Type != core.TimeType || f.Int64 != 1700000000*int64(time.Second) {
+		t.Errorf("when = %+v, want epoch 1700000000", f)
+	}
+	if tags, ok := entry.Fields[2].Any.([]any); !ok || len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("tags = %#v, want [a]", entry.Fields[2].Any)
+	}
+	if f := entry.Fields[3]; f.Type != core.DurationType || f.Int64 != int64(2*time.Second) {
+		t.Errorf("wait = %+v, want 2s", f)
+	}
+	if v := halfToFloat(0x7c00); !math.IsInf(v, 1) {
+		t.Errorf("halfToFloat(0x7c00) = %v, want +Inf", v)
+	}
+}
@@ -0,0 +1,22 @@
+// Package decoder reads the binary log streams written by
+// formatter.MsgpackFormatter and formatter.CBORFormatter back into
+// core.Entry values, for tools that convert, filter or display logs.
+//
+//	f, _ := os.Open("app.log.msgpack")
+//	dec := decoder.NewMsgpackDecoder(f)
+//	for {
+//		entry, err := dec.Decode()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+//
+// The time, level, message and caller keys fill the matching Entry
+// fields; every other key, including top-level keys renamed through
+// formatter.EncoderConfig, becomes a Field in stream order. Times and
+// durations decode as TimeType and DurationType. The binary formats
+// carry fewer types than core.Field otherwise, so integers decode as
+// Int64Type, errors as StringType, and arrays, maps and nil as AnyType
+// holding []any, map[string]any or nil.
+package decoder
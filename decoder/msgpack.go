@@ -0,0 +1,193 @@
+package decoder
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// msgpackReader reads MessagePack values
+type msgpackReader struct {
+	r *bufio.Reader
+}
+
+// uint reads an n-byte big-endian unsigned integer
+func (m *msgpackReader) uint(n int) (uint64, error) {
+	b, err := readN(m.r, uint64(n))
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (m *msgpackReader) readValue(depth int) (any, error) {
+	c, err := m.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return m.readMap(uint64(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return m.readArray(uint64(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return m.readString(uint64(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		n, err := m.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readN(m.r, n)
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		n, err := m.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return m.readExt(n)
+	case 0xca:
+		v, err := m.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := m.uint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		v, err := m.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+		return v, nil
+	case 0xd0:
+		v, err := m.uint(1)
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := m.uint(2)
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := m.uint(4)
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := m.uint(8)
+		return int64(v), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return m.readExt(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		n, err := m.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return m.readString(n)
+	case 0xdc, 0xdd: // array 16, 32
+		n, err := m.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return m.readArray(n, depth)
+	case 0xde, 0xdf: // map 16, 32
+		n, err := m.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return m.readMap(n, depth)
+	}
+	return nil, fmt.Errorf("%w: invalid MessagePack type 0x%02x", ErrFormat, c)
+}
+
+func (m *msgpackReader) readString(n uint64) (any, error) {
+	b, err := readN(m.r, n)
+	return string(b), err
+}
+
+func (m *msgpackReader) readArray(n uint64, depth int) (any, error) {
+	if err := checkItems(n, depth); err != nil {
+		return nil, err
+	}
+	a := make([]any, 0, min(n, 64))
+	for range n {
+		v, err := m.readValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (m *msgpackReader) readMap(n uint64, depth int) (any, error) {
+	if err := checkItems(n, depth); err != nil {
+		return nil, err
+	}
+	ps := make([]pair, 0, min(n, 64))
+	for range n {
+		k, err := m.readValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := m.readValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, pair{key: mapKey(k), value: v})
+	}
+	return ps, nil
+}
+
+// msgpackDurationExt is the extension type formatter.MsgpackFormatter
+// writes durations with
+const msgpackDurationExt = 1
+
+// readExt reads an extension of n data bytes. The timestamp type (-1)
+// becomes a time.Time, the duration type a time.Duration, other types
+// their raw data.
+func (m *msgpackReader) readExt(n uint64) (any, error) {
+	typ, err := m.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	b, err := readN(m.r, n)
+	if err != nil {
+		return nil, err
+	}
+	if typ == msgpackDurationExt {
+		if n != 8 {
+			return nil, fmt.Errorf("%w: duration of %d bytes", ErrFormat, n)
+		}
+		return time.Duration(binary.BigEndian.Uint64(b)), nil
+	}
+	if int8(typ) != -1 {
+		return b, nil
+	}
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(b)
+		sec := int64(binary.BigEndian.Uint64(b[4:]))
+		return time.Unix(sec, int64(nsec)), nil
+	}
+	return nil, fmt.Errorf("%w: timestamp of %d bytes", ErrFormat, n)
+}
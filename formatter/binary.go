@@ -0,0 +1,360 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// binaryEncoding writes the primitive values of a binary format
+type binaryEncoding interface {
+	appendMapHeader(buf *bytes.Buffer, n int)
+	appendArrayHeader(buf *bytes.Buffer, n int)
+	appendNil(buf *bytes.Buffer)
+	appendString(buf *bytes.Buffer, s string)
+	appendInt(buf *bytes.Buffer, v int64)
+	appendFloat(buf *bytes.Buffer, v float64)
+	appendBool(buf *bytes.Buffer, v bool)
+	appendTime(buf *bytes.Buffer, t time.Time)
+	appendDuration(buf *bytes.Buffer, d time.Duration)
+}
+
+// formatBinaryToBuffer writes an entry as one map with the keys and value
+// semantics of the JSONFormatter: time, level, message, the optional
+// caller map and one key per field. The top-level key names come from
+// cfg.Encoder. Entries are self-delimiting, so a stream is their
+// concatenation.
+func formatBinaryToBuffer(enc binaryEncoding, cfg *Config, entry *core.Entry, buf *bytes.Buffer) {
+	timeKey := keyName(cfg.Encoder.TimeKey, "time")
+	levelKey := keyName(cfg.Encoder.LevelKey, "level")
+	messageKey := keyName(cfg.Encoder.MessageKey, "message")
+	callerKey := keyName(cfg.Encoder.CallerKey, "caller")
+	hasCaller := cfg.IncludeCaller && entry.Caller.Defined && callerKey != ""
+
+	n := len(entry.Fields)
+	for _, key := range [...]string{timeKey, levelKey, messageKey} {
+		if key != "" {
+			n++
+		}
+	}
+	if hasCaller {
+		n++
+	}
+	enc.appendMapHeader(buf, n)
+
+	if timeKey != "" {
+		enc.appendString(buf, timeKey)
+		enc.appendTime(buf, entry.Time)
+	}
+	if levelKey != "" {
+		enc.appendString(buf, levelKey)
+		enc.appendString(buf, entry.Level.String())
+	}
+	if messageKey != "" {
+		enc.appendString(buf, messageKey)
+		enc.appendString(buf, entry.Message)
+	}
+
+	if hasCaller {
+		enc.appendString(buf, callerKey)
+		if entry.Caller.Function != "" {
+			enc.appendMapHeader(buf, 3)
+		} else {
+			enc.appendMapHeader(buf, 2)
+		}
+		enc.appendString(buf, "file")
+		enc.appendString(buf, entry.Caller.ShortFile)
+		enc.appendString(buf, "line")
+		enc.appendInt(buf, int64(entry.Caller.Line))
+		if entry.Caller.Function != "" {
+			enc.appendString(buf, "function")
+			enc.appendString(buf, entry.Caller.Function)
+		}
+	}
+
+	for _, field := range entry.Fields {
+		mark := buf.Len()
+		enc.appendString(buf, field.Key)
+		switch field.Type {
+		case core.StringType, core.ErrorType:
+			enc.appendString(buf, field.Str)
+		case core.IntType, core.Int64Type:
+			enc.appendInt(buf, field.Int64)
+		case core.DurationType:
+			enc.appendDuration(buf, time.Duration(field.Int64))
+		case core.Float64Type:
+			enc.appendFloat(buf, field.Float64)
+		case core.BoolType:
+			enc.appendBool(buf, field.Int64 == 1)
+		case core.TimeType:
+			enc.appendTime(buf, time.Unix(0, field.Int64))
+		default:
+			if err := appendBinaryAny(enc, buf, field.Any); err != nil {
+				// Replace the field with "<key>Error" like the JSONFormatter
+				buf.Truncate(mark)
+				enc.appendString(buf, field.Key+"Error")
+				enc.appendString(buf, err.Error())
+			}
+		}
+	}
+}
+
+// appendBinaryAny writes an AnyType value with the semantics of
+// appendJSONAny: fmt.Stringer and error values become strings. Maps,
+// slices, arrays, pointers and scalars are written natively, with map
+// keys sorted as by encoding/json. Structs, whose field tags only
+// encoding/json interprets, and json.Marshaler and encoding.TextMarshaler
+// values, at any depth, are encoded by encoding/json and converted to
+// native maps, arrays and scalars. On error the buffer may hold a partial
+// value and must be truncated by the caller.
+func appendBinaryAny(enc binaryEncoding, buf *bytes.Buffer, v any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	switch v := v.(type) {
+	case nil:
+		enc.appendNil(buf)
+		return nil
+	case json.Marshaler, encoding.TextMarshaler:
+		return appendBinaryJSON(enc, buf, v)
+	case fmt.Stringer:
+		enc.appendString(buf, v.String())
+		return nil
+	case error:
+		enc.appendString(buf, v.Error())
+		return nil
+	}
+	return appendBinaryReflect(enc, buf, reflect.ValueOf(v), 0)
+}
+
+// maxBinaryDepth bounds the nesting of Any values, as the decoder
+// package rejects deeper input; it also stops reference cycles
+const maxBinaryDepth = 64
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// appendBinaryReflect writes v natively, handing structs and marshalers
+// to appendBinaryJSON
+func appendBinaryReflect(enc binaryEncoding, buf *bytes.Buffer, v reflect.Value, depth int) error {
+	if depth >= maxBinaryDepth {
+		return fmt.Errorf("nesting exceeds %d levels", maxBinaryDepth)
+	}
+	if !v.IsValid() {
+		enc.appendNil(buf)
+		return nil
+	}
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			enc.appendNil(buf)
+			return nil
+		}
+		return appendBinaryJSON(enc, buf, v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		enc.appendBool(buf, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.appendInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n := v.Uint(); n <= math.MaxInt64 {
+			enc.appendInt(buf, int64(n))
+		} else {
+			enc.appendFloat(buf, float64(n))
+		}
+	case reflect.Float32, reflect.Float64:
+		enc.appendFloat(buf, v.Float())
+	case reflect.String:
+		enc.appendString(buf, v.String())
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			enc.appendNil(buf)
+			return nil
+		}
+		return appendBinaryReflect(enc, buf, v.Elem(), depth+1)
+	case reflect.Slice:
+		if v.IsNil() {
+			enc.appendNil(buf)
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are base64 strings as in JSON
+			enc.appendString(buf, base64.StdEncoding.EncodeToString(v.Bytes()))
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		enc.appendArrayHeader(buf, v.Len())
+		for i := range v.Len() {
+			if err := appendBinaryReflect(enc, buf, v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			enc.appendNil(buf)
+			return nil
+		}
+		return appendBinaryMap(enc, buf, v, depth)
+	case reflect.Struct:
+		return appendBinaryJSON(enc, buf, v.Interface())
+	default:
+		return &json.UnsupportedTypeError{Type: t}
+	}
+	return nil
+}
+
+// appendBinaryMap writes a map with its keys converted to strings and
+// sorted like encoding/json does
+func appendBinaryMap(enc binaryEncoding, buf *bytes.Buffer, v reflect.Value, depth int) error {
+	type member struct {
+		key   string
+		value reflect.Value
+	}
+	members := make([]member, 0, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		k := iter.Key()
+		var key string
+		switch {
+		case k.Kind() == reflect.String:
+			key = k.String()
+		case k.Type().Implements(textMarshalerType):
+			if k.Kind() == reflect.Pointer && k.IsNil() {
+				break
+			}
+			text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return err
+			}
+			key = string(text)
+		case k.CanInt():
+			key = strconv.FormatInt(k.Int(), 10)
+		case k.CanUint():
+			key = strconv.FormatUint(k.Uint(), 10)
+		default:
+			return &json.UnsupportedTypeError{Type: v.Type()}
+		}
+		members = append(members, member{key: key, value: iter.Value()})
+	}
+	slices.SortFunc(members, func(a, b member) int { return strings.Compare(a.key, b.key) })
+
+	enc.appendMapHeader(buf, len(members))
+	for _, m := range members {
+		enc.appendString(buf, m.key)
+		if err := appendBinaryReflect(enc, buf, m.value, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendBinaryJSON encodes v with encoding/json, which calls marshalers,
+// validates their output and applies struct tags, and writes the result
+// natively
+func appendBinaryJSON(enc binaryEncoding, buf *bytes.Buffer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := readJSONValue(dec)
+	if err != nil {
+		return err
+	}
+	appendBinaryValue(enc, buf, value)
+	return nil
+}
+
+// jsonMember is an object member; objects keep their order so struct
+// fields are written in declaration order like in the JSON output
+type jsonMember struct {
+	key   string
+	value any
+}
+
+// readJSONValue reads the next value from dec as nil, bool, string,
+// json.Number, []any or []jsonMember
+func readJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('['):
+		a := []any{}
+		for dec.More() {
+			v, err := readJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err = dec.Token()
+		return a, err
+	case json.Delim('{'):
+		m := []jsonMember{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := readJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, jsonMember{key: key.(string), value: v})
+		}
+		_, err = dec.Token()
+		return m, err
+	}
+	return tok, nil
+}
+
+// appendBinaryValue writes a value returned by readJSONValue. Numbers
+// that fit an int64 are integers, others floats.
+func appendBinaryValue(enc binaryEncoding, buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		enc.appendNil(buf)
+	case bool:
+		enc.appendBool(buf, v)
+	case string:
+		enc.appendString(buf, v)
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			enc.appendInt(buf, n)
+		} else {
+			f, _ := strconv.ParseFloat(string(v), 64)
+			enc.appendFloat(buf, f)
+		}
+	case []any:
+		enc.appendArrayHeader(buf, len(v))
+		for _, elem := range v {
+			appendBinaryValue(enc, buf, elem)
+		}
+	case []jsonMember:
+		enc.appendMapHeader(buf, len(v))
+		for _, m := range v {
+			enc.appendString(buf, m.key)
+			appendBinaryValue(enc, buf, m.value)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// CBORFormatter formats log entries as CBOR (RFC 8949) maps with the keys
+// and field semantics of the JSONFormatter. Times are RFC 3339 strings
+// with nanoseconds under tag 0, durations integer nanoseconds under the
+// RFC 9581 duration tag 1002. Use the decoder package to read the stream
+// back.
+type CBORFormatter struct {
+	Config
+}
+
+// NewCBORFormatter creates a new CBOR formatter
+func NewCBORFormatter(cfg Config) *CBORFormatter {
+	return &CBORFormatter{Config: cfg}
+}
+
+// Format formats an entry as CBOR
+func (f *CBORFormatter) Format(entry *core.Entry) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	formatBinaryToBuffer(cborEncoding{}, &f.Config, entry, buf)
+
+	result := make([]byte, buf.Len())
+	copy(result, buf.Bytes())
+	return result, nil
+}
+
+// FormatTo formats an entry as CBOR and writes it directly to the writer
+func (f *CBORFormatter) FormatTo(entry *core.Entry, w io.Writer) error {
+	buf := getBuffer()
+
+	formatBinaryToBuffer(cborEncoding{}, &f.Config, entry, buf)
+
+	_, err := w.Write(buf.Bytes())
+	putBuffer(buf)
+	return err
+}
+
+// FormatEntry formats an entry as CBOR into the given buffer (implements BufferFormatter).
+func (f *CBORFormatter) FormatEntry(entry *core.Entry, buf *bytes.Buffer) {
+	formatBinaryToBuffer(cborEncoding{}, &f.Config, entry, buf)
+}
+
+// CBOR major types
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+)
+
+// cborEncoding writes CBOR values with the shortest argument encoding
+type cborEncoding struct{}
+
+// appendCBORHead writes a major type with its argument
+func appendCBORHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		buf.Write(binary.BigEndian.AppendUint16(buf.AvailableBuffer(), uint16(n)))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		buf.Write(binary.BigEndian.AppendUint32(buf.AvailableBuffer(), uint32(n)))
+	default:
+		buf.WriteByte(major | 27)
+		buf.Write(binary.BigEndian.AppendUint64(buf.AvailableBuffer(), n))
+	}
+}
+
+func (cborEncoding) appendMapHeader(buf *bytes.Buffer, n int) {
+	appendCBORHead(buf, cborMap, uint64(n))
+}
+
+func (cborEncoding) appendArrayHeader(buf *bytes.Buffer, n int) {
+	appendCBORHead(buf, cborArray, uint64(n))
+}
+
+func (cborEncoding) appendNil(buf *bytes.Buffer) {
+	buf.WriteByte(0xf6)
+}
+
+func (cborEncoding) appendString(buf *bytes.Buffer, s string) {
+	appendCBORHead(buf, cborText, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func (cborEncoding) appendInt(buf *bytes.Buffer, v int64) {
+	if v >= 0 {
+		appendCBORHead(buf, cborUint, uint64(v))
+	} else {
+		appendCBORHead(buf, cborNegInt, uint64(-1-v))
+	}
+}
+
+func (cborEncoding) appendFloat(buf *bytes.Buffer, v float64) {
+	buf.WriteByte(0xfb)
+	buf.Write(binary.BigEndian.AppendUint64(buf.AvailableBuffer(), math.Float64bits(v)))
+}
+
+func (cborEncoding) appendBool(buf *bytes.Buffer, v bool) {
+	if v {
+		buf.WriteByte(0xf5)
+	} else {
+		buf.WriteByte(0xf4)
+	}
+}
+
+// appendTime writes tag 0, a standard date/time string. The text is
+// formatted in place behind a one-byte length, which is shortened to the
+// inline form for strings under 24 bytes.
+func (cborEncoding) appendTime(buf *bytes.Buffer, t time.Time) {
+	appendCBORHead(buf, cborTag, 0)
+	buf.WriteByte(cborText | 24)
+	buf.WriteByte(0)
+	start := buf.Len()
+	buf.Write(t.AppendFormat(buf.AvailableBuffer(), time.RFC3339Nano))
+	n := buf.Len() - start
+	b := buf.Bytes()
+	if n < 24 {
+		b[start-2] = cborText | byte(n)
+		copy(b[start-1:], b[start:])
+		buf.Truncate(buf.Len() - 1)
+	} else {
+		b[start-1] = byte(n)
+	}
+}
+
+// cborDurationTag is the duration tag of RFC 9581. Its content is a map
+// that here holds only key -9, the duration in integer nanoseconds.
+const cborDurationTag = 1002
+
+func (e cborEncoding) appendDuration(buf *bytes.Buffer, d time.Duration) {
+	appendCBORHead(buf, cborTag, cborDurationTag)
+	appendCBORHead(buf, cborMap, 1)
+	e.appendInt(buf, -9)
+	e.appendInt(buf, int64(d))
+}
@@ -0,0 +1,71 @@
+package formatter
+
+import (
+	"io"
+	"os"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// ColorMode controls ANSI colors in text output
+type ColorMode int
+
+const (
+	// ColorNever writes plain text (default)
+	ColorNever ColorMode = iota
+	// ColorAuto colors output when a handler resolves the formatter for a
+	// terminal, see ColorEnabled
+	ColorAuto
+	// ColorAlways colors output regardless of the writer and environment
+	ColorAlways
+)
+
+// ANSI escape sequences used by the text formatters
+const (
+	ansiReset  = "\x1b[0m"
+	ansiFaint  = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+	ansiBold   = "\x1b[1m"
+)
+
+// levelColors are the colors of the level names
+var levelColors = [...]string{
+	core.DebugLevel: ansiBlue,
+	core.InfoLevel:  ansiGreen,
+	core.WarnLevel:  ansiYellow,
+	core.ErrorLevel: ansiRed,
+	core.FatalLevel: ansiBold + ansiRed,
+	core.PanicLevel: ansiBold + ansiRed,
+}
+
+// ColorEnabled reports whether output to w should be colored: NO_COLOR
+// (any non-empty value) disables colors, FORCE_COLOR (any non-empty value
+// other than "0" or "false") enables them, and otherwise w must be a
+// terminal.
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	switch force := os.Getenv("FORCE_COLOR"); force {
+	case "":
+	case "0", "false":
+		return false
+	default:
+		return true
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,264 @@
+package formatter
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// DevConfig holds configuration for the development formatter
+type DevConfig struct {
+	Config
+	// MessageWidth pads messages to this many characters so fields line
+	// up in a column (default: 40)
+	MessageWidth int
+	// Start is the reference for relative timestamps (default: the time
+	// the formatter was created). Ignored when TimestampFormat is set.
+	Start time.Time
+}
+
+// DevFormatter formats log entries for reading in a terminal during
+// development: aligned time and level columns, the message padded to a
+// fixed width, fields on the same line and the caller as a clickable
+// path:line. Multi-line string and error values, such as stack traces,
+// are rendered below the entry, indented by Config.Indent. It is not
+// meant to be parsed.
+type DevFormatter struct {
+	DevConfig
+	color bool
+}
+
+// NewDevFormatter creates a new development formatter. Timestamps are
+// relative to Start unless TimestampFormat is set.
+func NewDevFormatter(cfg DevConfig) *DevFormatter {
+	if cfg.MessageWidth <= 0 {
+		cfg.MessageWidth = 40
+	}
+	if cfg.Start.IsZero() {
+		cfg.Start = time.Now()
+	}
+	if cfg.Indent == "" {
+		cfg.Indent = DefaultIndent
+	}
+	return &DevFormatter{DevConfig: cfg, color: cfg.Color == ColorAlways}
+}
+
+// ForWriter resolves ColorAuto for w (implements WriterAwareFormatter).
+// Without ColorAuto the formatter itself is returned.
+func (f *DevFormatter) ForWriter(w io.Writer) Formatter {
+	if f.Color != ColorAuto {
+		return f
+	}
+	c := *f
+	c.color = ColorEnabled(w)
+	return &c
+}
+
+// Format formats an entry for development
+func (f *DevFormatter) Format(entry *core.Entry) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	f.formatDevToBuffer(entry, buf)
+
+	result := make([]byte, buf.Len())
+	copy(result, buf.Bytes())
+	return result, nil
+}
+
+// FormatTo formats an entry and writes it directly to the writer
+func (f *DevFormatter) FormatTo(entry *core.Entry, w io.Writer) error {
+	buf := getBuffer()
+
+	f.formatDevToBuffer(entry, buf)
+
+	_, err := w.Write(buf.Bytes())
+	putBuffer(buf)
+	return err
+}
+
+// FormatEntry formats an entry into the given buffer (implements BufferFormatter).
+func (f *DevFormatter) FormatEntry(entry *core.Entry, buf *bytes.Buffer) {
+	f.formatDevToBuffer(entry, buf)
+}
+
+// devLevels are the level names padded to a common width
+var devLevels = [...]string{
+	core.DebugLevel: "DEBUG",
+	core.InfoLevel:  "INFO ",
+	core.WarnLevel:  "WARN ",
+	core.ErrorLevel: "ERROR",
+	core.FatalLevel: "FATAL",
+	core.PanicLevel: "PANIC",
+}
+
+// relativeWidth is the width of a relative timestamp such as "  12.345s"
+const relativeWidth = 9
+
+// spaces is a run of padding
+const spaces = "                                                                "
+
+// formatDevToBuffer writes the formatted entry into the given buffer
+func (f *DevFormatter) formatDevToBuffer(entry *core.Entry, buf *bytes.Buffer) {
+	// Time column
+	f.paint(buf, ansiFaint)
+	if f.TimestampFormat != "" {
+		buf.Write(entry.Time.AppendFormat(buf.AvailableBuffer(), f.TimestampFormat))
+	} else {
+		f.appendRelative(buf, entry.Time.Sub(f.Start))
+	}
+	f.paint(buf, ansiReset)
+	buf.WriteByte(' ')
+
+	// Level column
+	if int(entry.Level) < len(devLevels) {
+		f.paint(buf, levelColors[entry.Level])
+		buf.WriteString(devLevels[entry.Level])
+		f.paint(buf, ansiReset)
+	} else {
+		buf.WriteString("?????")
+	}
+	buf.WriteByte(' ')
+
+	// Message, padded when fields or the caller follow. The width is
+	// measured on the escaped form, from its last line break if kept.
+	mark := buf.Len()
+	appendEscaped(buf, entry.Message, f.Escape, f.Indent)
+	hasCaller := f.IncludeCaller && entry.Caller.Defined
+	if len(entry.Fields) > 0 || hasCaller {
+		written := buf.Bytes()[mark:]
+		if i := bytes.LastIndexByte(written, '\n'); i >= 0 {
+			written = written[i+1:]
+		}
+		pad(buf, f.MessageWidth-utf8.RuneCount(written))
+	}
+
+	// Single-line fields
+	multiline := false
+	for _, field := range entry.Fields {
+		if isMultiline(field) {
+			multiline = true
+			continue
+		}
+		buf.WriteByte(' ')
+		f.paint(buf, ansiCyan)
+		appendTextKey(buf, field.Key, f.Escape)
+		f.paint(buf, ansiReset)
+		buf.WriteByte('=')
+		if field.Type == core.ErrorType {
+			f.paint(buf, ansiRed)
+			appendTextFieldValue(buf, field, f.Escape, f.Indent)
+			f.paint(buf, ansiReset)
+		} else {
+			appendTextFieldValue(buf, field, f.Escape, f.Indent)
+		}
+	}
+
+	// Caller as path:line, which terminals and editors make clickable
+	if hasCaller {
+		buf.WriteString("  ")
+		f.paint(buf, ansiFaint)
+		buf.WriteString(entry.Caller.File)
+		buf.WriteByte(':')
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(entry.Caller.Line), 10))
+		f.paint(buf, ansiReset)
+	}
+	buf.WriteByte('\n')
+
+	// Multi-line values, indented below. Tabs are kept for readable stack
+	// traces; other control characters are escaped unless EscapeNone.
+	if !multiline {
+		return
+	}
+	lineMode := EscapeIndent
+	if f.Escape == EscapeNone {
+		lineMode = EscapeNone
+	}
+	for _, field := range entry.Fields {
+		if !isMultiline(field) {
+			continue
+		}
+		buf.WriteString(f.Indent)
+		f.paint(buf, ansiCyan)
+		appendTextKey(buf, field.Key, f.Escape)
+		f.paint(buf, ansiReset)
+		buf.WriteString(":\n")
+		isErr := field.Type == core.ErrorType
+		for rest := strings.TrimRight(field.Str, "\n"); ; {
+			line, more, found := strings.Cut(rest, "\n")
+			buf.WriteString(f.Indent)
+			buf.WriteString(f.Indent)
+			if isErr {
+				f.paint(buf, ansiRed)
+			}
+			appendEscaped(buf, line, lineMode, "")
+			if isErr {
+				f.paint(buf, ansiReset)
+			}
+			buf.WriteByte('\n')
+			if !found {
+				break
+			}
+			rest = more
+		}
+	}
+}
+
+// paint writes an ANSI escape when colors are enabled.
+func (f *DevFormatter) paint(buf *bytes.Buffer, code string) {
+	if f.color {
+		buf.WriteString(code)
+	}
+}
+
+// appendRelative writes d as seconds with millisecond precision,
+// right-aligned to relativeWidth, e.g. "   1.234s".
+func (f *DevFormatter) appendRelative(buf *bytes.Buffer, d time.Duration) {
+	start := buf.Len()
+	ms := d.Milliseconds()
+	if ms < 0 {
+		buf.WriteByte('-')
+		ms = -ms
+	}
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), ms/1000, 10))
+	buf.WriteByte('.')
+	frac := ms % 1000
+	if frac < 100 {
+		buf.WriteByte('0')
+	}
+	if frac < 10 {
+		buf.WriteByte('0')
+	}
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), frac, 10))
+	buf.WriteByte('s')
+
+	// Right-align by shifting the number behind the padding
+	if n := buf.Len() - start; n < relativeWidth {
+		p := relativeWidth - n
+		buf.WriteString(spaces[:p])
+		b := buf.Bytes()[start:]
+		copy(b[p:], b[:n])
+		copy(b[:p], spaces[:p])
+	}
+}
+
+// pad writes n spaces, and at least one.
+func pad(buf *bytes.Buffer, n int) {
+	n = max(n, 1)
+	for n > len(spaces) {
+		buf.WriteString(spaces)
+		n -= len(spaces)
+	}
+	buf.WriteString(spaces[:n])
+}
+
+// isMultiline reports whether a field value is rendered below the entry.
+func isMultiline(field core.Field) bool {
+	return (field.Type == core.StringType || field.Type == core.ErrorType) &&
+		strings.IndexByte(field.Str, '\n') >= 0
+}
@@ -13,6 +13,40 @@
 // pre-computes level bracket strings (" [INFO] ", etc.) so that the
 // most common path is a single WriteString call.
 //
+// JSONFormatter takes its key names and time, level, duration and caller
+// encodings from EncoderConfig. The key prefixes are rendered once at
+// construction, so custom keys cost nothing per entry. NewECSFormatter,
+// NewGCPFormatter and NewDatadogFormatter are JSONFormatter presets that
+// also map levels to vendor severity names and reshape the caller object.
+// JSON strings are checked against a table of safe ASCII bytes; only
+// other bytes take the escaping path, which also replaces invalid UTF-8,
+// escapes C1 controls and U+2028/U+2029, and escapes HTML characters or
+// non-ASCII as set by EncoderConfig.Escape.
+//
+// LogfmtFormatter quotes and escapes keys and values the way logfmt parsers
+// expect; ParseLogfmt is its inverse and is used to verify round trips.
+//
+// TemplateFormatter compiles a log4j-style pattern into a slice of append
+// operations at construction; padding and truncation are applied in place
+// in the output buffer, so a compiled pattern formats without allocations.
+//
+// MsgpackFormatter and CBORFormatter share the entry layout and
+// EncoderConfig key names of the JSONFormatter and write integers and
+// floats in binary instead of formatting them as text. Durations carry
+// their own extension type or tag. AnyType maps, slices and scalars are
+// written natively; only structs and marshalers go through encoding/json,
+// and their output is converted to native maps and arrays. The decoder
+// package reads the output back.
+//
+// DevFormatter renders entries for reading during development and also
+// implements BufferFormatter, so it plugs into the console handlers.
+//
+// TextFormatter and DevFormatter escape control characters in messages,
+// keys and values by default (see EscapeMode), so logged input cannot
+// forge log lines. They optionally color their output. ColorAuto is
+// resolved per destination through the WriterAwareFormatter interface,
+// which console handlers call once at construction with their writer.
+//
 // Buffers larger than 64 KiB are not returned to the pool to prevent
 // a single large log line from permanently inflating memory usage.
 package formatter
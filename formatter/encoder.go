@@ -0,0 +1,211 @@
+package formatter
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// OmitKey as a key name in EncoderConfig leaves the value out of the output
+const OmitKey = "-"
+
+// LevelEncoding controls how the level is written
+type LevelEncoding int
+
+const (
+	// LevelUpper writes the level name in upper case, e.g. "INFO" (default)
+	LevelUpper LevelEncoding = iota
+	// LevelLower writes the level name in lower case, e.g. "info"
+	LevelLower
+	// LevelNumeric writes the numeric level, from 0 for Debug to 5 for Panic
+	LevelNumeric
+)
+
+// TimeEncoding controls how the entry time and time fields are written
+type TimeEncoding int
+
+const (
+	// TimeLayout writes a string in Config.TimestampFormat (default)
+	TimeLayout TimeEncoding = iota
+	// TimeEpochSeconds writes seconds since the Unix epoch as a float
+	TimeEpochSeconds
+	// TimeEpochMillis writes milliseconds since the Unix epoch
+	TimeEpochMillis
+	// TimeEpochNanos writes nanoseconds since the Unix epoch
+	TimeEpochNanos
+)
+
+// DurationEncoding controls how duration fields are written
+type DurationEncoding int
+
+const (
+	// DurationNanos writes integer nanoseconds (default)
+	DurationNanos DurationEncoding = iota
+	// DurationMillis writes milliseconds as a float
+	DurationMillis
+	// DurationSeconds writes seconds as a float
+	DurationSeconds
+	// DurationString writes a string such as "1.5s"
+	DurationString
+)
+
+// CallerEncoding controls the file path of the caller
+type CallerEncoding int
+
+const (
+	// CallerShort writes the file name only, e.g. "server.go" (default)
+	CallerShort CallerEncoding = iota
+	// CallerFull writes the absolute path the binary was built from
+	CallerFull
+	// CallerTrimmed writes the package import path and file name, e.g.
+	// "github.com/acme/app/server/server.go", independent of where the
+	// module was checked out
+	CallerTrimmed
+)
+
+// EncoderConfig controls the key names and value encodings of the
+// JSONFormatter; the binary formatters use its key names. The zero value
+// produces the default output.
+type EncoderConfig struct {
+	// TimeKey is the key of the entry time (default: "time")
+	TimeKey string
+	// LevelKey is the key of the level (default: "level")
+	LevelKey string
+	// MessageKey is the key of the message (default: "message")
+	MessageKey string
+	// CallerKey is the key of the caller object (default: "caller")
+	CallerKey string
+	// LevelEncoding controls how the level is written (default: LevelUpper)
+	LevelEncoding LevelEncoding
+	// TimeEncoding controls how times are written (default: TimeLayout)
+	TimeEncoding TimeEncoding
+	// TimeUTC converts times to UTC before formatting them with the layout
+	TimeUTC bool
+	// DurationEncoding controls how durations are written (default: DurationNanos)
+	DurationEncoding DurationEncoding
+	// CallerEncoding controls the caller file path (default: CallerShort)
+	CallerEncoding CallerEncoding
+	// Escape adds HTML-safe or ASCII-only escaping of strings (default: none)
+	Escape JSONEscape
+}
+
+// lowerLevels are the lowercase level names
+var lowerLevels = [...]string{
+	core.DebugLevel: "debug",
+	core.InfoLevel:  "info",
+	core.WarnLevel:  "warn",
+	core.ErrorLevel: "error",
+	core.FatalLevel: "fatal",
+	core.PanicLevel: "panic",
+}
+
+// keyName returns the key name, def when it is unset, or "" when the key
+// is omitted.
+func keyName(name, def string) string {
+	switch name {
+	case OmitKey:
+		return ""
+	case "":
+		return def
+	}
+	return name
+}
+
+// jsonKey returns `"key":` for a key name, or "" when the key is omitted.
+func jsonKey(name, def string, esc JSONEscape) string {
+	name = keyName(name, def)
+	if name == "" {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	appendJSONString(&buf, name, esc)
+	buf.WriteString(`":`)
+	return buf.String()
+}
+
+// appendLevel writes the level per enc
+func appendLevel(buf *bytes.Buffer, level core.Level, enc LevelEncoding) {
+	switch enc {
+	case LevelNumeric:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(level), 10))
+	case LevelLower:
+		buf.WriteByte('"')
+		if int(level) < len(lowerLevels) {
+			buf.WriteString(lowerLevels[level])
+		} else {
+			buf.WriteString("unknown")
+		}
+		buf.WriteByte('"')
+	default:
+		buf.WriteByte('"')
+		buf.WriteString(level.String())
+		buf.WriteByte('"')
+	}
+}
+
+// appendTime writes t per enc, quoting layouts
+func appendTime(buf *bytes.Buffer, t time.Time, enc TimeEncoding, layout string, utc bool) {
+	switch enc {
+	case TimeEpochSeconds:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), float64(t.UnixNano())/1e9, 'f', -1, 64))
+	case TimeEpochMillis:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), t.UnixMilli(), 10))
+	case TimeEpochNanos:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), t.UnixNano(), 10))
+	default:
+		if utc {
+			t = t.UTC()
+		}
+		buf.WriteByte('"')
+		buf.Write(t.AppendFormat(buf.AvailableBuffer(), layout))
+		buf.WriteByte('"')
+	}
+}
+
+// appendDuration writes d per enc
+func appendDuration(buf *bytes.Buffer, d time.Duration, enc DurationEncoding) {
+	switch enc {
+	case DurationMillis:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), float64(d)/float64(time.Millisecond), 'f', -1, 64))
+	case DurationSeconds:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), d.Seconds(), 'f', -1, 64))
+	case DurationString:
+		buf.WriteByte('"')
+		buf.WriteString(d.String())
+		buf.WriteByte('"')
+	default:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(d), 10))
+	}
+}
+
+// appendCallerFile writes the JSON-escaped caller file path per enc
+func appendCallerFile(buf *bytes.Buffer, caller core.CallerInfo, enc *EncoderConfig) {
+	switch enc.CallerEncoding {
+	case CallerFull:
+		appendJSONString(buf, caller.File, enc.Escape)
+	case CallerTrimmed:
+		if pkg := packagePath(caller.Function); pkg != "" {
+			appendJSONString(buf, pkg, enc.Escape)
+			buf.WriteByte('/')
+		}
+		appendJSONString(buf, caller.ShortFile, enc.Escape)
+	default:
+		appendJSONString(buf, caller.ShortFile, enc.Escape)
+	}
+}
+
+// packagePath returns the import path of the package of a function name
+// as reported by runtime.FuncForPC, e.g. "github.com/acme/app/server"
+// for "github.com/acme/app/server.(*Server).Run".
+func packagePath(function string) string {
+	lastSlash := strings.LastIndexByte(function, '/')
+	dot := strings.IndexByte(function[lastSlash+1:], '.')
+	if dot < 0 {
+		return ""
+	}
+	return function[:lastSlash+1+dot]
+}
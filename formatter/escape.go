@@ -0,0 +1,126 @@
+package formatter
+
+import "bytes"
+
+// EscapeMode controls how the text formatters write control characters in
+// messages, keys and string values
+type EscapeMode int
+
+const (
+	// EscapeControl writes newlines, tabs and other control characters as
+	// \n, \t or \u00XX, so logged input can never start a line that looks
+	// like a separate entry (default)
+	EscapeControl EscapeMode = iota
+	// EscapeIndent keeps newlines and tabs but prefixes every continuation
+	// line with Config.Indent; other control characters, carriage returns
+	// included, are escaped
+	EscapeIndent
+	// EscapeNone writes content verbatim. Use it only when everything
+	// logged is trusted, e.g. multi-line output from your own code.
+	EscapeNone
+)
+
+// DefaultIndent prefixes continuation lines in EscapeIndent mode
+const DefaultIndent = "    "
+
+// textSafe reports which bytes appendEscaped writes as is: everything but
+// ASCII controls and the lead bytes of C1 controls and U+2028/U+2029
+var textSafe = func() (safe [256]bool) {
+	for c := 0x20; c < len(safe); c++ {
+		safe[c] = c != 0x7f && c != 0xc2 && c != 0xe2
+	}
+	return safe
+}()
+
+// appendEscaped writes s to buf, escaping control characters per mode.
+// Besides ASCII control characters this covers the C1 controls
+// (U+0080-U+009F, e.g. the CSI of terminal escapes) and the Unicode line
+// and paragraph separators, which some viewers treat as line breaks.
+func appendEscaped(buf *bytes.Buffer, s string, mode EscapeMode, indent string) {
+	if mode != EscapeNone {
+		// Fast path: eight bytes at a time while all are printable ASCII,
+		// then one table lookup per byte until one needs a look
+		i := 0
+		if len(s) >= 8 {
+			for ; i+8 <= len(s) && printableASCII(s[i:i+8]); i += 8 {
+			}
+			// The last word may overlap bytes already checked
+			if i+8 > len(s) && printableASCII(s[len(s)-8:]) {
+				i = len(s)
+			}
+		}
+		for ; i < len(s); i++ {
+			if !textSafe[s[i]] {
+				appendEscapedFrom(buf, s, i, mode, indent)
+				return
+			}
+		}
+	}
+	buf.WriteString(s)
+}
+
+// printableASCII reports whether the 8 bytes of s are all in 0x20-0x7e
+func printableASCII(s string) bool {
+	const lo, hi = 0x0101010101010101, 0x8080808080808080
+	w := uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 |
+		uint64(s[4])<<32 | uint64(s[5])<<40 | uint64(s[6])<<48 | uint64(s[7])<<56
+	// A byte's high bit is set if it is non-ASCII, below 0x20 or 0x7f;
+	// borrows only spill into bytes above one that already matched
+	return (w|(w-0x20*lo)|((w^0x7f*lo)-lo))&hi == 0
+}
+
+// appendEscapedFrom is appendEscaped for s whose first byte needing
+// attention is at i
+func appendEscapedFrom(buf *bytes.Buffer, s string, i int, mode EscapeMode, indent string) {
+	start := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		if textSafe[c] {
+			continue
+		}
+		var r rune
+		switch {
+		case c < 0x20 || c == 0x7f:
+			r = rune(c)
+		case c == 0xc2 && i+1 < len(s) && s[i+1] >= 0x80 && s[i+1] <= 0x9f:
+			r = rune(s[i+1]) // C1 control, encoded as 0xc2 0x80-0x9f
+		case c == 0xe2 && i+2 < len(s) && s[i+1] == 0x80 && (s[i+2] == 0xa8 || s[i+2] == 0xa9):
+			r = 0x2028 + rune(s[i+2]-0xa8) // U+2028, U+2029
+		default:
+			continue
+		}
+
+		buf.WriteString(s[start:i])
+		switch {
+		case mode == EscapeIndent && r == '\n':
+			buf.WriteByte('\n')
+			buf.WriteString(indent)
+		case mode == EscapeIndent && r == '\t':
+			buf.WriteByte('\t')
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteString(`\u`)
+			buf.WriteByte(hexChars[r>>12&0xf])
+			buf.WriteByte(hexChars[r>>8&0xf])
+			buf.WriteByte(hexChars[r>>4&0xf])
+			buf.WriteByte(hexChars[r&0xf])
+		}
+
+		// Skip the rest of a multi-byte sequence
+		switch {
+		case r >= 0x2000:
+			i += 2
+		case r >= 0x80:
+			i++
+		}
+		start = i + 1
+	}
+	buf.WriteString(s[start:])
+}
+
+var hexChars = [16]byte{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'a', 'b', 'c', 'd', 'e', 'f'}
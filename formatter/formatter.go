@@ -29,12 +29,29 @@ type BufferFormatter interface {
 	FormatEntry(entry *core.Entry, buf *bytes.Buffer)
 }
 
+// WriterAwareFormatter is an optional interface for formatters whose
+// output depends on the destination, e.g. colors only on terminals.
+// Handlers call ForWriter once at construction and use the result.
+type WriterAwareFormatter interface {
+	// ForWriter returns the formatter to use for writing to w
+	ForWriter(w io.Writer) Formatter
+}
+
 // Config holds common formatter configuration
 type Config struct {
 	// IncludeCaller enables caller information in log output
 	IncludeCaller bool
 	// TimestampFormat specifies the time format (empty for RFC3339)
 	TimestampFormat string
+	// Color controls ANSI colors in text output (default: ColorNever)
+	Color ColorMode
+	// Escape controls how text output writes newlines and other control
+	// characters in messages, keys and values (default: EscapeControl)
+	Escape EscapeMode
+	// Indent prefixes continuation lines with EscapeIndent (default: DefaultIndent)
+	Indent string
+	// Encoder controls key names and value encodings in JSON output
+	Encoder EncoderConfig
 }
 
 // bufferPool is a pool of bytes.Buffer to reduce allocations
@@ -1,10 +1,16 @@
 package formatter
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/philipp01105/nlog/core"
 )
@@ -223,3 +229,1212 @@ func BenchmarkJSONFormatter(b *testing.B) {
 		_, _ = f.Format(entry)
 	}
 }
+
+func BenchmarkTextFormatter_FormatEntry(b *testing.B) {
+	f := NewTextFormatter(Config{})
+	// Plain ASCII strings, which the escapers pass through unchanged
+	entry := &core.Entry{
+		Time:    time.Now(),
+		Level:   core.InfoLevel,
+		Message: "user logged in successfully",
+		Fields: []core.Field{
+			{Key: "user_id", Type: core.IntType, Int64: 12345},
+			{Key: "ip", Type: core.StringType, Str: "192.168.1.1"},
+			{Key: "method", Type: core.StringType, Str: "oauth"},
+			{Key: "path", Type: core.StringType, Str: "/api/v1/login"},
+		},
+	}
+	var buf bytes.Buffer
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	}
+}
+
+func BenchmarkJSONFormatter_FormatEntry(b *testing.B) {
+	f := NewJSONFormatter(Config{})
+	// Plain ASCII strings, which the escapers pass through unchanged
+	entry := &core.Entry{
+		Time:    time.Now(),
+		Level:   core.InfoLevel,
+		Message: "user logged in successfully",
+		Fields: []core.Field{
+			{Key: "user_id", Type: core.IntType, Int64: 12345},
+			{Key: "ip", Type: core.StringType, Str: "192.168.1.1"},
+			{Key: "method", Type: core.StringType, Str: "oauth"},
+			{Key: "path", Type: core.StringType, Str: "/api/v1/login"},
+		},
+	}
+	var buf bytes.Buffer
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	}
+}
+
+type jsonPoint struct{ X, Y int }
+
+func (p jsonPoint) MarshalJSON() ([]byte, error) {
+	return []byte(`[` + strconv.Itoa(p.X) + `, ` + strconv.Itoa(p.Y) + `]`), nil
+}
+
+type textLevel int
+
+func (l textLevel) MarshalText() ([]byte, error) { return []byte("level-" + strconv.Itoa(int(l))), nil }
+
+type stringerID int
+
+func (id stringerID) String() string { return "id#" + strconv.Itoa(int(id)) }
+
+type badMarshaler struct{}
+
+func (badMarshaler) MarshalJSON() ([]byte, error) { return nil, errors.New("boom") }
+
+type invalidMarshaler struct{}
+
+func (invalidMarshaler) MarshalJSON() ([]byte, error) { return []byte(`{not json`), nil }
+
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("nil config") }
+
+func TestJSONFormatter_AnyValues(t *testing.T) {
+	f := NewJSONFormatter(Config{Encoder: EncoderConfig{TimeKey: OmitKey, LevelKey: OmitKey, MessageKey: OmitKey}})
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"map", map[string]any{"a": 1, "b": []string{"x"}}, `{"v":{"a":1,"b":["x"]}}`},
+		{"struct", struct {
+			Name string `json:"name"`
+			Tag  string `json:"tag"`
+		}{"n", "<b>"}, `{"v":{"name":"n","tag":"<b>"}}`},
+		{"json.Marshaler", jsonPoint{1, 2}, `{"v":[1,2]}`},
+		{"TextMarshaler", textLevel(3), `{"v":"level-3"}`},
+		{"Stringer", stringerID(7), `{"v":"id#7"}`},
+		{"error", errors.New("not found"), `{"v":"not found"}`},
+		{"nil", nil, `{"v":null}`},
+		{"marshal error", badMarshaler{}, `{"vError":"json: error calling MarshalJSON for type ...: boom"}`},
+		{"invalid output", invalidMarshaler{}, `{"vError":"json: error calling MarshalJSON for type ...: invalid character 'n' ..."}`},
+		{"unsupported", make(chan int), `{"vError":"json: unsupported type: chan int"}`},
+		{"panic", panicStringer{}, `{"vError":"panic: nil config"}`},
+		{"NaN in value", []float64{math.NaN()}, `{"vError":"json: unsupported value: NaN"}`},
+	}
+	for _, tt := range tests {
+		entry := &core.Entry{Fields: []core.Field{{Key: "v", Type: core.AnyType, Any: tt.value}}}
+		out, err := f.Format(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// "..." stands for parts of encoding/json messages that may change
+		prefix, rest, _ := strings.Cut(tt.want+"\n", "...")
+		ok := strings.HasPrefix(string(out), prefix)
+		for _, part := range strings.Split(rest, "...") {
+			ok = ok && strings.Contains(string(out), part)
+		}
+		if !ok {
+			t.Errorf("%s: got %s want %s", tt.name, out, tt.want)
+		}
+		if !json.Valid(out) {
+			t.Errorf("%s: invalid JSON %s", tt.name, out)
+		}
+	}
+}
+
+func TestJSONFormatter_NonFiniteFloats(t *testing.T) {
+	f := NewJSONFormatter(Config{})
+	entry := &core.Entry{
+		Level:   core.InfoLevel,
+		Message: "stats",
+		Fields: []core.Field{
+			{Key: "nan", Type: core.Float64Type, Float64: math.NaN()},
+			{Key: "inf", Type: core.Float64Type, Float64: math.Inf(1)},
+			{Key: "neg", Type: core.Float64Type, Float64: math.Inf(-1)},
+		},
+	}
+	out, _ := f.Format(entry)
+	var data map[string]any
+	if err := json.Unmarshal(out, &data); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", out, err)
+	}
+	if data["nan"] != "NaN" || data["inf"] != "+Inf" || data["neg"] != "-Inf" {
+		t.Errorf("Unexpected non-finite encodings: %s", out)
+	}
+}
+
+func TestJSONFormatter_EncoderKeys(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 500_000_000, time.FixedZone("CET", 3600)),
+		Level:   core.WarnLevel,
+		Message: "slow request",
+		Fields: []core.Field{
+			{Key: "took", Type: core.DurationType, Int64: int64(1500 * time.Millisecond)},
+		},
+	}
+
+	f := NewJSONFormatter(Config{Encoder: EncoderConfig{
+		TimeKey:       OmitKey,
+		LevelKey:      "severity",
+		MessageKey:    "msg",
+		LevelEncoding: LevelLower,
+	}})
+
+	out, err := f.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"severity":"warn","msg":"slow request","took":1500000000}` + "\n"
+	if string(out) != want {
+		t.Errorf("Format() = %s, want %s", out, want)
+	}
+
+	// Omitting every built-in key still produces valid JSON
+	f = NewJSONFormatter(Config{Encoder: EncoderConfig{
+		TimeKey: OmitKey, LevelKey: OmitKey, MessageKey: OmitKey,
+	}})
+	out, _ = f.Format(entry)
+	if want := `{"took":1500000000}` + "\n"; string(out) != want {
+		t.Errorf("Format() = %s, want %s", out, want)
+	}
+}
+
+func TestJSONFormatter_EncoderValues(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 500_000_000, time.FixedZone("CET", 3600)),
+		Level:   core.WarnLevel,
+		Message: "slow request",
+		Fields: []core.Field{
+			{Key: "took", Type: core.DurationType, Int64: int64(1500 * time.Millisecond)},
+		},
+	}
+
+	tests := []struct {
+		name string
+		enc  EncoderConfig
+		key  string
+		want string
+	}{
+		{"level numeric", EncoderConfig{LevelEncoding: LevelNumeric}, "level", `2`},
+		{"level upper", EncoderConfig{}, "level", `"WARN"`},
+		{"time layout", EncoderConfig{}, "time", `"2026-02-18T14:00:00.5+01:00"`},
+		{"time utc", EncoderConfig{TimeUTC: true}, "time", `"2026-02-18T13:00:00.5Z"`},
+		{"time seconds", EncoderConfig{TimeEncoding: TimeEpochSeconds}, "time", `1771419600.5`},
+		{"time millis", EncoderConfig{TimeEncoding: TimeEpochMillis}, "time", `1771419600500`},
+		{"time nanos", EncoderConfig{TimeEncoding: TimeEpochNanos}, "time", `1771419600500000000`},
+		{"duration nanos", EncoderConfig{}, "took", `1500000000`},
+		{"duration millis", EncoderConfig{DurationEncoding: DurationMillis}, "took", `1500`},
+		{"duration seconds", EncoderConfig{DurationEncoding: DurationSeconds}, "took", `1.5`},
+		{"duration string", EncoderConfig{DurationEncoding: DurationString}, "took", `"1.5s"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewJSONFormatter(Config{Encoder: tt.enc})
+			out, err := f.Format(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data map[string]json.RawMessage
+			if err := json.Unmarshal(out, &data); err != nil {
+				t.Fatalf("Invalid JSON %s: %v", out, err)
+			}
+			if got := string(data[tt.key]); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFormatter_EncoderCaller(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 500_000_000, time.FixedZone("CET", 3600)),
+		Level:   core.WarnLevel,
+		Message: "slow request",
+		Fields: []core.Field{
+			{Key: "took", Type: core.DurationType, Int64: int64(1500 * time.Millisecond)},
+		},
+		Caller: core.CallerInfo{
+			File:      "/home/ci/src/app/server/handler.go",
+			ShortFile: "handler.go",
+			Line:      42,
+			Function:  "github.com/acme/app/server.(*Server).Serve",
+			Defined:   true,
+		},
+	}
+
+	tests := []struct {
+		enc  CallerEncoding
+		want string
+	}{
+		{CallerShort, "handler.go"},
+		{CallerFull, "/home/ci/src/app/server/handler.go"},
+		{CallerTrimmed, "github.com/acme/app/server/handler.go"},
+	}
+	for _, tt := range tests {
+		f := NewJSONFormatter(Config{
+			IncludeCaller: true,
+			Encoder:       EncoderConfig{CallerKey: "src", CallerEncoding: tt.enc},
+		})
+		out, _ := f.Format(entry)
+		var data struct {
+			Src struct {
+				File string `json:"file"`
+				Line int    `json:"line"`
+			} `json:"src"`
+		}
+		if err := json.Unmarshal(out, &data); err != nil {
+			t.Fatalf("Invalid JSON %s: %v", out, err)
+		}
+		if data.Src.File != tt.want || data.Src.Line != 42 {
+			t.Errorf("CallerEncoding %d: caller = %+v, want file %q", tt.enc, data.Src, tt.want)
+		}
+	}
+
+	if got := packagePath("main.main"); got != "main" {
+		t.Errorf("packagePath(main.main) = %q, want main", got)
+	}
+	if got := packagePath("github.com/acme/app.v2/pkg.Func"); got != "github.com/acme/app.v2/pkg" {
+		t.Errorf("packagePath = %q, want github.com/acme/app.v2/pkg", got)
+	}
+}
+
+func TestJSONFormatter_EncoderZeroAlloc(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 500_000_000, time.FixedZone("CET", 3600)),
+		Level:   core.WarnLevel,
+		Message: "slow request",
+		Fields: []core.Field{
+			{Key: "took", Type: core.DurationType, Int64: int64(1500 * time.Millisecond)},
+		},
+		Caller: core.CallerInfo{
+			File:      "/home/ci/src/app/server/handler.go",
+			ShortFile: "handler.go",
+			Line:      42,
+			Function:  "github.com/acme/app/server.(*Server).Serve",
+			Defined:   true,
+		},
+	}
+	f := NewJSONFormatter(Config{IncludeCaller: true, Encoder: EncoderConfig{
+		TimeKey:          "ts",
+		LevelEncoding:    LevelLower,
+		TimeEncoding:     TimeEpochMillis,
+		DurationEncoding: DurationSeconds,
+		CallerEncoding:   CallerTrimmed,
+	}})
+	var buf bytes.Buffer
+	buf.Grow(1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	})
+	if allocs != 0 {
+		t.Errorf("FormatEntry allocated %.0f times per entry", allocs)
+	}
+}
+
+func TestAppendJSONString_Escape(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		esc  JSONEscape
+		want string
+	}{
+		{"ascii", `say "hi"\`, 0, `say \"hi\"\\`},
+		{"control", "a\nb\x1b", 0, `a\nb\u001b`},
+		{"utf8", "grüße 🚀", 0, "grüße 🚀"},
+		{"invalid utf8", "a\xffb\xe2\x82", 0, `a\ufffdb\ufffd\ufffd`},
+		{"line separators", "a\u2028b\u2029", 0, `a\u2028b\u2029`},
+		{"html off", "<a&b>", 0, "<a&b>"},
+		{"html", "<a&b>", JSONEscapeHTML, `\u003ca\u0026b\u003e`},
+		{"ascii only", "grüße 🚀", JSONEscapeASCII, `gr\u00fc\u00dfe \ud83d\ude80`},
+		{"ascii invalid", "\xff", JSONEscapeASCII, `\ufffd`},
+		{"combined", "<ü>", JSONEscapeHTML | JSONEscapeASCII, `\u003c\u00fc\u003e`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			appendJSONString(&buf, tt.in, tt.esc)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("appendJSONString(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+
+			// The result is a valid JSON string that decodes to valid input unchanged
+			var s string
+			if err := json.Unmarshal([]byte(`"`+buf.String()+`"`), &s); err != nil {
+				t.Fatalf("invalid JSON string %s: %v", buf.String(), err)
+			}
+			if utf8.ValidString(tt.in) && s != tt.in {
+				t.Errorf("decoded %q, want %q", s, tt.in)
+			}
+		})
+	}
+}
+
+func TestJSONFormatter_EncoderEscape(t *testing.T) {
+	f := NewJSONFormatter(Config{Encoder: EncoderConfig{
+		TimeKey: OmitKey,
+		Escape:  JSONEscapeHTML | JSONEscapeASCII,
+	}})
+	entry := &core.Entry{
+		Level:   core.InfoLevel,
+		Message: "<b>café</b>",
+		Fields: []core.Field{
+			{Key: "ключ", Type: core.StringType, Str: "a&b"},
+			{Key: "obj", Type: core.AnyType, Any: map[string]string{"k": "<é>"}},
+		},
+	}
+	out, err := f.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"INFO","message":"\u003cb\u003ecaf\u00e9\u003c/b\u003e",` +
+		`"\u043a\u043b\u044e\u0447":"a\u0026b","obj":{"k":"\u003c\u00e9\u003e"}}` + "\n"
+	if string(out) != want {
+		t.Errorf("Format() = %s, want %s", out, want)
+	}
+}
+
+func TestJSONFormatter_EscapeZeroAlloc(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 500_000_000, time.FixedZone("CET", 3600)),
+		Level:   core.WarnLevel,
+		Message: "slow request",
+		Fields: []core.Field{
+			{Key: "took", Type: core.DurationType, Int64: int64(1500 * time.Millisecond)},
+			{Key: "path", Type: core.StringType, Str: "/api/v1/users?id=42"},
+		},
+	}
+	for _, esc := range []JSONEscape{0, JSONEscapeHTML, JSONEscapeASCII} {
+		f := NewJSONFormatter(Config{Encoder: EncoderConfig{Escape: esc}})
+		var buf bytes.Buffer
+		buf.Grow(1024)
+		allocs := testing.AllocsPerRun(100, func() {
+			buf.Reset()
+			f.FormatEntry(entry, &buf)
+		})
+		if allocs != 0 {
+			t.Errorf("Escape %d: FormatEntry allocated %.0f times per entry", esc, allocs)
+		}
+	}
+}
+
+func TestJSONFormatter_PresetOutput(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 0, time.FixedZone("CET", 3600)),
+		Level:   core.ErrorLevel,
+		Message: "disk full",
+		Fields:  []core.Field{{Key: "free", Type: core.IntType, Int64: 0}},
+		Caller: core.CallerInfo{
+			ShortFile: "store.go",
+			Line:      42,
+			Function:  "main.flush",
+			Defined:   true,
+		},
+	}
+	tests := []struct {
+		name string
+		f    *JSONFormatter
+		want string
+	}{
+		{
+			"ecs",
+			NewECSFormatter(Config{IncludeCaller: true}),
+			`{"@timestamp":"2026-02-18T13:00:00Z","log.level":"error","message":"disk full","ecs.version":"` + ECSVersion + `",` +
+				`"log.origin":{"file":{"name":"store.go","line":42},"function":"main.flush"},"free":0}`,
+		},
+		{
+			"gcp",
+			NewGCPFormatter(Config{IncludeCaller: true}),
+			`{"time":"2026-02-18T13:00:00Z","severity":"ERROR","message":"disk full",` +
+				`"logging.googleapis.com/sourceLocation":{"file":"store.go","line":"42","function":"main.flush"},"free":0}`,
+		},
+		{
+			"datadog",
+			NewDatadogFormatter(Config{IncludeCaller: true}),
+			`{"timestamp":"2026-02-18T13:00:00Z","status":"error","message":"disk full",` +
+				`"caller":{"file":"store.go","line":42},"logger":{"method_name":"main.flush"},"free":0}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.f.Format(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want+"\n" {
+				t.Errorf("Format() =\n%s\nwant\n%s", out, tt.want)
+			}
+			if !json.Valid(out) {
+				t.Errorf("Invalid JSON: %s", out)
+			}
+		})
+	}
+
+	// Without a function Datadog gets no logger object
+	entry.Caller.Function = ""
+	out, _ := NewDatadogFormatter(Config{IncludeCaller: true}).Format(entry)
+	if !bytes.Contains(out, []byte(`"message":"disk full","caller":{"file":"store.go","line":42},"free":0}`)) {
+		t.Errorf("Format() without function = %s", out)
+	}
+}
+
+func TestJSONFormatter_PresetLevels(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 0, time.UTC),
+		Message: "disk full",
+	}
+	tests := []struct {
+		f    *JSONFormatter
+		key  string
+		want []string
+	}{
+		{NewECSFormatter(Config{}), "log.level", []string{"debug", "info", "warn", "error", "fatal", "panic"}},
+		{NewGCPFormatter(Config{}), "severity", []string{"DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL", "ALERT"}},
+		{NewDatadogFormatter(Config{}), "status", []string{"debug", "info", "warning", "error", "critical", "alert"}},
+	}
+	for _, tt := range tests {
+		for level, want := range tt.want {
+			entry.Level = core.Level(level)
+			out, _ := tt.f.Format(entry)
+			var data map[string]any
+			if err := json.Unmarshal(out, &data); err != nil {
+				t.Fatalf("Invalid JSON %s: %v", out, err)
+			}
+			if data[tt.key] != want {
+				t.Errorf("%s for %s = %v, want %s", tt.key, core.Level(level), data[tt.key], want)
+			}
+		}
+	}
+}
+
+func TestJSONFormatter_PresetEncodings(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 14, 0, 0, 0, time.FixedZone("CET", 3600)),
+		Level:   core.InfoLevel,
+		Message: "disk full",
+		Fields:  []core.Field{{Key: "free", Type: core.IntType, Int64: 0}},
+	}
+	f := NewDatadogFormatter(Config{Encoder: EncoderConfig{
+		LevelKey:     "severity", // overridden by the preset
+		TimeEncoding: TimeEpochMillis,
+	}})
+	out, _ := f.Format(entry)
+	want := `{"timestamp":1771419600000,"status":"info","message":"disk full","free":0}` + "\n"
+	if string(out) != want {
+		t.Errorf("Format() = %s, want %s", out, want)
+	}
+}
+
+func TestTemplateFormatter_Pattern(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 13, 4, 5, 678_000_000, time.UTC),
+		Level:   core.InfoLevel,
+		Message: "request done",
+		Fields: []core.Field{
+			{Key: "request_id", Type: core.StringType, Str: "abc123def456"},
+			{Key: "status", Type: core.IntType, Int64: 200},
+		},
+		Caller: core.CallerInfo{ShortFile: "server.go", Line: 42, Function: "main.serve", Defined: true},
+	}
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"%time{15:04:05.000} %-5level [%caller] %msg %fields", "13:04:05.678 INFO  [server.go:42] request done request_id=abc123def456 status=200"},
+		{"%time %level %msg", "2026-02-18T13:04:05Z INFO request done"},
+		{"[%.6field{request_id}] %msg %fields", "[abc123] request done status=200"},
+		{"%5level|%-8.4msg|", " INFO|requ    |"},
+		{"%file:%line %func 100%%", "server.go:42 main.serve 100%"},
+		{"%field{missing}%msg%n  %fields", "request done\n  request_id=abc123def456 status=200"},
+	}
+	for _, tt := range tests {
+		f, err := NewTemplateFormatter(TemplateConfig{Pattern: tt.pattern})
+		if err != nil {
+			t.Fatalf("NewTemplateFormatter(%q) error = %v", tt.pattern, err)
+		}
+		out, _ := f.Format(entry)
+		if string(out) != tt.want+"\n" {
+			t.Errorf("%q:\ngot  %q\nwant %q", tt.pattern, out, tt.want+"\n")
+		}
+	}
+}
+
+func TestTemplateFormatter_NoFields(t *testing.T) {
+	f, err := NewTemplateFormatter(TemplateConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 13, 4, 5, 678_000_000, time.UTC),
+		Level:   core.InfoLevel,
+		Message: "request done",
+	}
+	out, _ := f.Format(entry)
+	if want := "2026-02-18T13:04:05Z [INFO] request done\n"; string(out) != want {
+		t.Errorf("Format() = %q, want %q", out, want)
+	}
+}
+
+func TestTemplateFormatter_Escape(t *testing.T) {
+	f, _ := NewTemplateFormatter(TemplateConfig{Pattern: "%msg %field{user}"})
+	entry := &core.Entry{
+		Level:   core.InfoLevel,
+		Message: "line1\nline2",
+		Fields:  []core.Field{{Key: "user", Type: core.StringType, Str: "eve\n[ERROR] forged"}},
+	}
+	out, _ := f.Format(entry)
+	if want := `line1\nline2 eve\n[ERROR] forged` + "\n"; string(out) != want {
+		t.Errorf("Format() = %q, want %q", out, want)
+	}
+}
+
+func TestTemplateFormatter_Errors(t *testing.T) {
+	for _, pattern := range []string{
+		"%bogus",
+		"%time{15:04",
+		"%field",
+		"%level{x}",
+		"%.msg",
+		"%5",
+		"%99999msg",
+	} {
+		if _, err := NewTemplateFormatter(TemplateConfig{Pattern: pattern}); !errors.Is(err, ErrTemplateSyntax) {
+			t.Errorf("NewTemplateFormatter(%q) error = %v, want ErrTemplateSyntax", pattern, err)
+		}
+	}
+}
+
+func TestTemplateFormatter_ZeroAlloc(t *testing.T) {
+	f, _ := NewTemplateFormatter(TemplateConfig{
+		Pattern: "%time{15:04:05.000} %-5level [%caller] %.8field{request_id} %-20msg %fields",
+	})
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 13, 4, 5, 678_000_000, time.UTC),
+		Level:   core.InfoLevel,
+		Message: "request done",
+		Fields: []core.Field{
+			{Key: "request_id", Type: core.StringType, Str: "abc123def456"},
+			{Key: "status", Type: core.IntType, Int64: 200},
+		},
+		Caller: core.CallerInfo{ShortFile: "server.go", Line: 42, Function: "main.serve", Defined: true},
+	}
+	var buf bytes.Buffer
+	buf.Grow(1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	})
+	if allocs != 0 {
+		t.Errorf("FormatEntry allocated %.0f times per entry", allocs)
+	}
+}
+
+func TestMsgpackFormatter_Bytes(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Unix(1, 2),
+		Level:   core.InfoLevel,
+		Message: "hi",
+		Fields:  []core.Field{{Key: "n", Type: core.IntType, Int64: -33}},
+	}
+	out, _ := NewMsgpackFormatter(Config{}).Format(entry)
+	want := []byte{
+		0x84,
+		0xa4, 't', 'i', 'm', 'e', 0xd7, 0xff, 0, 0, 0, 0x08, 0, 0, 0, 0x01, // timestamp 64
+		0xa5, 'l', 'e', 'v', 'e', 'l', 0xa4, 'I', 'N', 'F', 'O',
+		0xa7, 'm', 'e', 's', 's', 'a', 'g', 'e', 0xa2, 'h', 'i',
+		0xa1, 'n', 0xd0, 0xdf, // int 8
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("Format() = % x\nwant       % x", out, want)
+	}
+}
+
+func TestCBORFormatter_Bytes(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Unix(0, 0).UTC(),
+		Level:   core.InfoLevel,
+		Message: "hi",
+		Fields:  []core.Field{{Key: "n", Type: core.IntType, Int64: -500}},
+	}
+	out, _ := NewCBORFormatter(Config{}).Format(entry)
+	want := []byte{0xa4, 0x64, 't', 'i', 'm', 'e', 0xc0, 0x74}
+	want = append(want, "1970-01-01T00:00:00Z"...)
+	want = append(want, 0x65, 'l', 'e', 'v', 'e', 'l', 0x64, 'I', 'N', 'F', 'O')
+	want = append(want, 0x67, 'm', 'e', 's', 's', 'a', 'g', 'e', 0x62, 'h', 'i')
+	want = append(want, 0x61, 'n', 0x39, 0x01, 0xf3) // -1-499
+	if !bytes.Equal(out, want) {
+		t.Errorf("Format() = % x\nwant       % x", out, want)
+	}
+}
+
+func TestBinaryFormatters_EncoderKeys(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Unix(1, 0),
+		Level:   core.WarnLevel,
+		Message: "hi",
+		Fields:  []core.Field{{Key: "took", Type: core.DurationType, Int64: int64(time.Second)}},
+		Caller:  core.CallerInfo{ShortFile: "a.go", Line: 3, Defined: true},
+	}
+	f := NewMsgpackFormatter(Config{IncludeCaller: true, Encoder: EncoderConfig{
+		TimeKey:    OmitKey,
+		LevelKey:   "severity",
+		MessageKey: "msg",
+		CallerKey:  "src",
+	}})
+	out, _ := f.Format(entry)
+	want := []byte{
+		0x84,
+		0xa8, 's', 'e', 'v', 'e', 'r', 'i', 't', 'y', 0xa4, 'W', 'A', 'R', 'N',
+		0xa3, 'm', 's', 'g', 0xa2, 'h', 'i',
+		0xa3, 's', 'r', 'c', 0x82, 0xa4, 'f', 'i', 'l', 'e', 0xa4, 'a', '.', 'g', 'o', 0xa4, 'l', 'i', 'n', 'e', 0x03,
+		0xa4, 't', 'o', 'o', 'k', 0xd7, 0x01, 0, 0, 0, 0, 0x3b, 0x9a, 0xca, 0x00, // duration extension
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("Format() = % x\nwant       % x", out, want)
+	}
+
+	f.Encoder = EncoderConfig{CallerKey: OmitKey}
+	out, _ = NewCBORFormatter(f.Config).Format(entry)
+	tail := []byte{0x64, 't', 'o', 'o', 'k', 0xd9, 0x03, 0xea, 0xa1, 0x28, 0x1a, 0x3b, 0x9a, 0xca, 0x00}
+	if out[0] != 0xa4 || !bytes.HasSuffix(out, tail) {
+		t.Errorf("Format() = % x, want a 4-entry map ending in % x", out, tail)
+	}
+}
+
+func TestBinaryFormatters_ZeroAlloc(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Now(),
+		Level:   core.ErrorLevel,
+		Message: "request failed",
+		Fields: []core.Field{
+			{Key: "status", Type: core.IntType, Int64: 503},
+			{Key: "took", Type: core.DurationType, Int64: int64(time.Second)},
+			{Key: "ratio", Type: core.Float64Type, Float64: 0.5},
+			{Key: "at", Type: core.TimeType, Int64: time.Now().UnixNano()},
+		},
+		Caller: core.CallerInfo{ShortFile: "main.go", Line: 9, Function: "main.main", Defined: true},
+	}
+	for _, f := range []BufferFormatter{
+		NewMsgpackFormatter(Config{IncludeCaller: true}),
+		NewCBORFormatter(Config{IncludeCaller: true}),
+	} {
+		var buf bytes.Buffer
+		buf.Grow(1024)
+		allocs := testing.AllocsPerRun(100, func() {
+			buf.Reset()
+			f.FormatEntry(entry, &buf)
+		})
+		if allocs != 0 {
+			t.Errorf("%T.FormatEntry allocated %.0f times per entry", f, allocs)
+		}
+	}
+}
+
+func TestBinaryFormatters_Any(t *testing.T) {
+	type point struct {
+		B int      `json:"b"`
+		A []string `json:"a,omitempty"`
+	}
+	entry := &core.Entry{
+		Time:    time.Unix(1, 2),
+		Level:   core.InfoLevel,
+		Message: "hi",
+		Fields: []core.Field{
+			{Key: "v", Type: core.AnyType, Any: point{B: 1, A: []string{"x"}}},
+			{Key: "n", Type: core.AnyType, Any: nil},
+			{Key: "c", Type: core.AnyType, Any: make(chan int)},
+		},
+	}
+	out, _ := NewMsgpackFormatter(Config{}).Format(entry)
+	want := []byte{
+		0x86,
+		0xa4, 't', 'i', 'm', 'e', 0xd7, 0xff, 0, 0, 0, 0x08, 0, 0, 0, 0x01,
+		0xa5, 'l', 'e', 'v', 'e', 'l', 0xa4, 'I', 'N', 'F', 'O',
+		0xa7, 'm', 'e', 's', 's', 'a', 'g', 'e', 0xa2, 'h', 'i',
+		0xa1, 'v', 0x82, 0xa1, 'b', 0x01, 0xa1, 'a', 0x91, 0xa1, 'x', // struct as map
+		0xa1, 'n', 0xc0, // nil
+		0xa6, 'c', 'E', 'r', 'r', 'o', 'r', 0xd9, 32,
+	}
+	want = append(want, "json: unsupported type: chan int"...)
+	if !bytes.Equal(out, want) {
+		t.Errorf("Format() = % x\nwant       % x", out, want)
+	}
+
+	entry.Fields = entry.Fields[:2]
+	out, _ = NewCBORFormatter(Config{}).Format(entry)
+	tail := []byte{
+		0x61, 'v', 0xa2, 0x61, 'b', 0x01, 0x61, 'a', 0x81, 0x61, 'x',
+		0x61, 'n', 0xf6,
+	}
+	if out[0] != 0xa5 || !bytes.HasSuffix(out, tail) {
+		t.Errorf("Format() = % x, want a 5-entry map ending in % x", out, tail)
+	}
+}
+
+func TestBinaryFormatters_AnyNative(t *testing.T) {
+	cycle := map[string]any{}
+	cycle["self"] = cycle
+	entry := &core.Entry{
+		Time:    time.Unix(1, 2),
+		Level:   core.InfoLevel,
+		Message: "hi",
+		Fields: []core.Field{
+			{Key: "m", Type: core.AnyType, Any: map[string]any{"z": []int{1, 2}, "a": 1.5, "t": time.Unix(0, 0).UTC()}},
+			{Key: "k", Type: core.AnyType, Any: map[int]bool{2: true}},
+			{Key: "b", Type: core.AnyType, Any: []byte("hi")},
+			{Key: "c", Type: core.AnyType, Any: cycle},
+		},
+	}
+	out, _ := NewMsgpackFormatter(Config{Encoder: EncoderConfig{TimeKey: OmitKey, LevelKey: OmitKey, MessageKey: OmitKey}}).Format(entry)
+	want := []byte{
+		0x84,
+		0xa1, 'm', 0x83, // keys sorted like encoding/json
+		0xa1, 'a', 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+		0xa1, 't', 0xb4, // time.Time through its json.Marshaler
+	}
+	want = append(want, "1970-01-01T00:00:00Z"...)
+	want = append(want,
+		0xa1, 'z', 0x92, 0x01, 0x02,
+		0xa1, 'k', 0x81, 0xa1, '2', 0xc3,
+		0xa1, 'b', 0xa4, 'a', 'G', 'k', '=',
+		0xa6, 'c', 'E', 'r', 'r', 'o', 'r', 0xb9,
+	)
+	want = append(want, "nesting exceeds 64 levels"...)
+	if !bytes.Equal(out, want) {
+		t.Errorf("Format() = % x\nwant       % x", out, want)
+	}
+}
+
+func TestTextFormatter_Color(t *testing.T) {
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 13, 0, 0, 0, time.UTC),
+		Level:   core.ErrorLevel,
+		Message: "failed",
+		Fields: []core.Field{
+			{Key: "user", Type: core.StringType, Str: "alice"},
+			{Key: "error", Type: core.ErrorType, Str: "boom"},
+		},
+	}
+
+	var buf bytes.Buffer
+	NewTextFormatter(Config{Color: ColorAlways}).FormatEntry(entry, &buf)
+	output := buf.String()
+	for _, want := range []string{
+		" [" + ansiRed + "ERROR" + ansiReset + "] ",
+		ansiCyan + "user" + ansiReset + "=alice",
+		ansiCyan + "error" + ansiReset + "=" + ansiRed + "boom" + ansiReset,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got: %q", want, output)
+		}
+	}
+
+	buf.Reset()
+	NewTextFormatter(Config{Color: ColorAuto}).FormatEntry(entry, &buf)
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("Expected ColorAuto to stay plain until resolved, got: %q", buf.String())
+	}
+}
+
+func TestTextFormatter_ColorZeroAlloc(t *testing.T) {
+	f := NewTextFormatter(Config{Color: ColorAlways})
+	entry := &core.Entry{
+		Time:    time.Now(),
+		Level:   core.WarnLevel,
+		Message: "slow request",
+		Fields: []core.Field{
+			{Key: "path", Type: core.StringType, Str: "/api"},
+			{Key: "error", Type: core.ErrorType, Str: "timeout"},
+		},
+	}
+	var buf bytes.Buffer
+	buf.Grow(512)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocs, got %v", allocs)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		noColor string
+		force   string
+		want    bool
+	}{
+		{"NotTerminal", "", "", false},
+		{"ForceColor", "", "1", true},
+		{"ForceColorOff", "", "0", false},
+		{"NoColor", "1", "", false},
+		{"NoColorWins", "1", "1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("FORCE_COLOR", tt.force)
+			if got := ColorEnabled(&bytes.Buffer{}); got != tt.want {
+				t.Errorf("ColorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A regular file is never a terminal
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if ColorEnabled(f) {
+		t.Error("Expected no colors for a regular file")
+	}
+}
+
+func TestTextFormatter_ForWriter(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "1")
+
+	auto := NewTextFormatter(Config{Color: ColorAuto})
+	resolved := auto.ForWriter(&bytes.Buffer{}).(*TextFormatter)
+	if !resolved.color || auto.color {
+		t.Error("Expected ForWriter to return a colored copy")
+	}
+
+	plain := NewTextFormatter(Config{})
+	if plain.ForWriter(&bytes.Buffer{}) != Formatter(plain) {
+		t.Error("Expected ForWriter without ColorAuto to return the formatter itself")
+	}
+}
+
+func TestDevFormatter_Layout(t *testing.T) {
+	start := time.Date(2026, 2, 18, 13, 0, 0, 0, time.UTC)
+	f := NewDevFormatter(DevConfig{
+		Config:       Config{IncludeCaller: true},
+		MessageWidth: 12,
+		Start:        start,
+	})
+
+	entry := &core.Entry{
+		Time:    start.Add(1234 * time.Millisecond),
+		Level:   core.InfoLevel,
+		Message: "started",
+		Fields: []core.Field{
+			{Key: "port", Type: core.IntType, Int64: 8080},
+			{Key: "stack", Type: core.StringType, Str: "main.main()\n\t/app/main.go:10\n"},
+			{Key: "error", Type: core.ErrorType, Str: "boom"},
+		},
+		Caller: core.CallerInfo{File: "/app/main.go", ShortFile: "main.go", Line: 42, Defined: true},
+	}
+
+	var buf bytes.Buffer
+	f.FormatEntry(entry, &buf)
+	want := "   1.234s INFO  started      port=8080 error=boom  /app/main.go:42\n" +
+		"    stack:\n" +
+		"        main.main()\n" +
+		"        \t/app/main.go:10\n"
+	if buf.String() != want {
+		t.Errorf("FormatEntry() =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	// Config.Indent sets the indentation of multi-line values
+	f.Indent = "  "
+	buf.Reset()
+	f.FormatEntry(entry, &buf)
+	want = "   1.234s INFO  started      port=8080 error=boom  /app/main.go:42\n" +
+		"  stack:\n" +
+		"    main.main()\n" +
+		"    \t/app/main.go:10\n"
+	if buf.String() != want {
+		t.Errorf("FormatEntry() with Indent =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestDevFormatter_Columns(t *testing.T) {
+	start := time.Now()
+	f := NewDevFormatter(DevConfig{Start: start})
+
+	var lines []string
+	for _, e := range []struct {
+		level core.Level
+		after time.Duration
+		msg   string
+	}{
+		{core.DebugLevel, 5 * time.Millisecond, "short"},
+		{core.WarnLevel, 75 * time.Second, "a somewhat longer message"},
+		{core.InfoLevel, time.Second, "escaped\tbell\x07"}, // wider once escaped
+	} {
+		out, _ := f.Format(&core.Entry{
+			Time:    start.Add(e.after),
+			Level:   e.level,
+			Message: e.msg,
+			Fields:  []core.Field{{Key: "k", Type: core.StringType, Str: "v"}},
+		})
+		lines = append(lines, string(out))
+	}
+
+	if lines[0][:9] != "   0.005s" || lines[1][:9] != "  75.000s" {
+		t.Errorf("Expected right-aligned relative times, got %q and %q", lines[0][:9], lines[1][:9])
+	}
+	col := strings.Index(lines[0], " k=v")
+	for i, line := range lines[1:] {
+		if got := strings.Index(line, " k=v"); got != col {
+			t.Errorf("Expected fields of line %d at column %d, got %d: %q", i+1, col, got, line)
+		}
+	}
+}
+
+func TestDevFormatter_ZeroAlloc(t *testing.T) {
+	f := NewDevFormatter(DevConfig{Config: Config{Color: ColorAlways}})
+	entry := &core.Entry{
+		Time:    time.Now(),
+		Level:   core.ErrorLevel,
+		Message: "request failed",
+		Fields: []core.Field{
+			{Key: "path", Type: core.StringType, Str: "/api"},
+			{Key: "error", Type: core.ErrorType, Str: "timeout\nwhile reading body"},
+		},
+	}
+	var buf bytes.Buffer
+	buf.Grow(1024)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocs, got %v", allocs)
+	}
+}
+
+func TestLogfmtFormatter_Output(t *testing.T) {
+	f := NewLogfmtFormatter(Config{IncludeCaller: true})
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 13, 0, 0, 0, time.UTC),
+		Level:   core.WarnLevel,
+		Message: "user said \"hi\"",
+		Fields: []core.Field{
+			{Key: "user", Type: core.StringType, Str: "alice"},
+			{Key: "query", Type: core.StringType, Str: "a=b c"},
+			{Key: "empty", Type: core.StringType, Str: ""},
+			{Key: "bad key=", Type: core.IntType, Int64: 7},
+			{Key: "csi", Type: core.StringType, Str: "\u009b31m"}, // C1 control, escaped like in text
+			{Key: "ok", Type: core.BoolType, Int64: 1},
+		},
+		Caller: core.CallerInfo{ShortFile: "main.go", Line: 42, Defined: true},
+	}
+
+	out, err := f.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `time=2026-02-18T13:00:00Z level=warn msg="user said \"hi\"" caller=main.go:42 user=alice query="a=b c" empty="" "bad key="=7 csi="\u009b31m" ok=true` + "\n"
+	if string(out) != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestLogfmtFormatter_RoundTrip(t *testing.T) {
+	f := NewLogfmtFormatter(Config{TimestampFormat: "2006-01-02 15:04:05"})
+	values := []string{
+		"plain",
+		"with space",
+		"quote\" and \\backslash",
+		"line\nbreak\r\ttab",
+		"ctrl\x00\x1b[31m",
+		"unicode ünïcödé ✓",
+		"=",
+		"bad key",
+		"bad_key",
+		"",
+	}
+	// Each value is also used as a field key
+	for _, v := range values {
+		entry := &core.Entry{
+			Time:    time.Date(2026, 2, 18, 13, 0, 0, 0, time.UTC),
+			Level:   core.InfoLevel,
+			Message: v,
+			Fields:  []core.Field{{Key: v, Type: core.StringType, Str: v}},
+		}
+		var buf bytes.Buffer
+		f.FormatEntry(entry, &buf)
+		if bytes.Count(buf.Bytes(), []byte{'\n'}) != 1 {
+			t.Errorf("%q: expected a single line, got %q", v, buf.String())
+		}
+
+		pairs, err := ParseLogfmt(buf.String())
+		if err != nil {
+			t.Fatalf("%q: ParseLogfmt(%q) error = %v", v, buf.String(), err)
+		}
+		want := []LogfmtPair{{"time", "2026-02-18 13:00:00"}, {"level", "info"}, {"msg", v}, {v, v}}
+		if len(pairs) != len(want) {
+			t.Fatalf("%q: got pairs %q, want %q", v, pairs, want)
+		}
+		for i := range want {
+			if pairs[i] != want[i] {
+				t.Errorf("%q: pair %d = %q, want %q", v, i, pairs[i], want[i])
+			}
+		}
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	pairs, err := ParseLogfmt(`a=1 flag b= c="x y" "d e"=2 "f=g"` + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LogfmtPair{{"a", "1"}, {"flag", ""}, {"b", ""}, {"c", "x y"}, {"d e", "2"}, {"f=g", ""}}
+	if len(pairs) != len(want) {
+		t.Fatalf("got %q, want %q", pairs, want)
+	}
+	for i := range want {
+		if pairs[i] != want[i] {
+			t.Errorf("pair %d = %q, want %q", i, pairs[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{`a="unterminated`, `="x"`, `a="x"b`, `a=b"c`, `a"b"=1`, `"a"b=1`, `"unterminated=1`} {
+		if _, err := ParseLogfmt(bad); !errors.Is(err, ErrLogfmtSyntax) {
+			t.Errorf("ParseLogfmt(%q) error = %v, want ErrLogfmtSyntax", bad, err)
+		}
+	}
+}
+
+func TestLogfmtFormatter_ZeroAlloc(t *testing.T) {
+	f := NewLogfmtFormatter(Config{})
+	entry := &core.Entry{
+		Time:    time.Now(),
+		Level:   core.InfoLevel,
+		Message: "request done",
+		Fields: []core.Field{
+			{Key: "path", Type: core.StringType, Str: "/api/users"},
+			{Key: "status", Type: core.IntType, Int64: 200},
+		},
+	}
+	var buf bytes.Buffer
+	buf.Grow(512)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocs, got %v", allocs)
+	}
+}
+
+func TestAppendEscaped(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		mode EscapeMode
+		want string
+	}{
+		{"Plain", "hello wörld", EscapeControl, "hello wörld"},
+		{"Newline", "a\nb\r\nc", EscapeControl, `a\nb\r\nc`},
+		{"Tab", "a\tb", EscapeControl, `a\tb`},
+		{"Control", "bell\x07 esc\x1b[31m del\x7f", EscapeControl, `bell\u0007 esc\u001b[31m del\u007f`},
+		{"C1", "csi\u009b1m", EscapeControl, `csi\u009b1m`},
+		{"LoneC2ASCII", "x\xc2a", EscapeControl, "x\xc2a"},
+		{"LoneC2Control", "a\xc2\nb", EscapeControl, "a\xc2\\nb"},
+		{"LoneC2End", "a\xc2", EscapeControl, "a\xc2"},
+		{"LineSeparator", "a\u2028b\u2029c", EscapeControl, `a\u2028b\u2029c`},
+		{"LongPlain", "user logged in successfully", EscapeControl, "user logged in successfully"},
+		{"LongControlInWord", "user logged\nin successfully", EscapeControl, `user logged\nin successfully`},
+		{"LongControlInTail", "user logged in\x7f", EscapeControl, `user logged in\u007f`},
+		{"LongNonASCII", "user logged in: wörld", EscapeControl, "user logged in: wörld"},
+		{"Indent", "a\nb\r\nc\td", EscapeIndent, "a\n> b\\r\n> c\td"},
+		{"IndentLoneCR", "a\rb", EscapeIndent, `a\rb`},
+		{"IndentControl", "a\x1b", EscapeIndent, `a\u001b`},
+		{"None", "a\nb\x1b", EscapeNone, "a\nb\x1b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			appendEscaped(&buf, tt.in, tt.mode, "> ")
+			if buf.String() != tt.want {
+				t.Errorf("appendEscaped(%q) = %q, want %q", tt.in, buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTextFormatter_LogInjection(t *testing.T) {
+	forged := "bob\n2026-01-01T00:00:00Z [ERROR] admin logged in"
+	entry := &core.Entry{
+		Time:    time.Date(2026, 2, 18, 13, 0, 0, 0, time.UTC),
+		Level:   core.InfoLevel,
+		Message: "login " + forged,
+		Fields: []core.Field{
+			{Key: "user", Type: core.StringType, Str: forged},
+			{Key: "bad\nkey", Type: core.ErrorType, Str: forged},
+		},
+	}
+
+	for _, f := range []Formatter{
+		NewTextFormatter(Config{}),
+		NewTextFormatter(Config{Color: ColorAlways}),
+	} {
+		out, _ := f.Format(entry)
+		if n := strings.Count(string(out), "\n"); n != 1 {
+			t.Errorf("%T: expected a single line, got %d: %q", f, n, out)
+		}
+	}
+
+	// The dev formatter renders multi-line values indented below the entry
+	out, _ := NewDevFormatter(DevConfig{Start: entry.Time}).Format(entry)
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if !strings.Contains(lines[0], `login bob\n2026`) {
+		t.Errorf("Expected escaped message, got %q", lines[0])
+	}
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(line, DefaultIndent) {
+			t.Errorf("Expected indented continuation line, got %q", line)
+		}
+	}
+
+	out, _ = NewTextFormatter(Config{Escape: EscapeIndent}).Format(entry)
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")[1:] {
+		if !strings.HasPrefix(line, DefaultIndent) {
+			t.Errorf("Expected indented continuation line, got %q", line)
+		}
+	}
+	if !strings.Contains(string(out), `bad\nkey=`) {
+		t.Errorf("Expected escaped key with EscapeIndent, got %q", out)
+	}
+
+	out, _ = NewTextFormatter(Config{Escape: EscapeNone}).Format(entry)
+	if !strings.Contains(string(out), "\n2026-01-01T00:00:00Z [ERROR] admin logged in") {
+		t.Errorf("Expected verbatim output with EscapeNone, got %q", out)
+	}
+}
+
+func TestTextFormatter_EscapeZeroAlloc(t *testing.T) {
+	f := NewTextFormatter(Config{})
+	entry := &core.Entry{
+		Time:    time.Now(),
+		Level:   core.InfoLevel,
+		Message: "line one\nline two",
+		Fields:  []core.Field{{Key: "input", Type: core.StringType, Str: "tab\there\x1b"}},
+	}
+	var buf bytes.Buffer
+	buf.Grow(512)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		f.FormatEntry(entry, &buf)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocs, got %v", allocs)
+	}
+}
@@ -2,16 +2,52 @@ package formatter
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/internal/jsonstr"
 )
 
-// JSONFormatter formats log entries as JSON
+// JSONFormatter formats log entries as JSON. Key names and value
+// encodings are set with Config.Encoder.
 type JSONFormatter struct {
 	Config
+
+	// Precomputed `"key":` prefixes, empty when omitted
+	timeKey    string
+	levelKey   string
+	messageKey string
+	callerKey  string
+
+	// Set by vendor presets
+	levelNames *[6]string    // level names replacing LevelEncoding
+	caller     *callerLayout // shape of the caller object
+	static     string        // constant fields written after the message
+}
+
+// callerLayout is the pre-rendered JSON around the caller file, line and
+// function, so presets can reshape the caller object without branches.
+type callerLayout struct {
+	open     string // before the file
+	line     string // between file and line
+	lineEnd  string // after the line
+	function string // before the function
+	close    string
+}
+
+// defaultCallerLayout writes {"file":"..","line":N,"function":".."}
+var defaultCallerLayout = &callerLayout{
+	open:     `{"file":"`,
+	line:     `","line":`,
+	function: `,"function":"`,
+	close:    `}`,
 }
 
 // NewJSONFormatter creates a new JSON formatter
@@ -19,7 +55,14 @@ func NewJSONFormatter(cfg Config) *JSONFormatter {
 	if cfg.TimestampFormat == "" {
 		cfg.TimestampFormat = time.RFC3339Nano
 	}
-	return &JSONFormatter{Config: cfg}
+	return &JSONFormatter{
+		Config:     cfg,
+		timeKey:    jsonKey(cfg.Encoder.TimeKey, "time", cfg.Encoder.Escape),
+		levelKey:   jsonKey(cfg.Encoder.LevelKey, "level", cfg.Encoder.Escape),
+		messageKey: jsonKey(cfg.Encoder.MessageKey, "message", cfg.Encoder.Escape),
+		callerKey:  jsonKey(cfg.Encoder.CallerKey, "caller", cfg.Encoder.Escape),
+		caller:     defaultCallerLayout,
+	}
 }
 
 // Format formats an entry as JSON
@@ -52,112 +95,217 @@ func (f *JSONFormatter) FormatEntry(entry *core.Entry, buf *bytes.Buffer) {
 
 // formatJSONToBuffer builds JSON manually into the buffer without allocations
 func (f *JSONFormatter) formatJSONToBuffer(entry *core.Entry, buf *bytes.Buffer) {
+	enc := &f.Encoder
 	buf.WriteByte('{')
+	start := buf.Len()
 
 	// Time field
-	buf.WriteString(`"time":"`)
-	buf.Write(entry.Time.AppendFormat(buf.AvailableBuffer(), f.TimestampFormat))
-	buf.WriteByte('"')
+	if f.timeKey != "" {
+		buf.WriteString(f.timeKey)
+		appendTime(buf, entry.Time, enc.TimeEncoding, f.TimestampFormat, enc.TimeUTC)
+	}
 
 	// Level field
-	buf.WriteString(`,"level":"`)
-	buf.WriteString(entry.Level.String())
-	buf.WriteByte('"')
+	if f.levelKey != "" {
+		jsonSeparator(buf, start)
+		buf.WriteString(f.levelKey)
+		if f.levelNames != nil && int(entry.Level) < len(f.levelNames) {
+			buf.WriteByte('"')
+			buf.WriteString(f.levelNames[entry.Level])
+			buf.WriteByte('"')
+		} else {
+			appendLevel(buf, entry.Level, enc.LevelEncoding)
+		}
+	}
 
 	// Message field
-	buf.WriteString(`,"message":"`)
-	appendJSONString(buf, entry.Message)
-	buf.WriteByte('"')
+	if f.messageKey != "" {
+		jsonSeparator(buf, start)
+		buf.WriteString(f.messageKey)
+		buf.WriteByte('"')
+		appendJSONString(buf, entry.Message, enc.Escape)
+		buf.WriteByte('"')
+	}
+
+	if f.static != "" {
+		jsonSeparator(buf, start)
+		buf.WriteString(f.static)
+	}
 
 	// Caller info if enabled
-	if f.IncludeCaller && entry.Caller.Defined {
-		buf.WriteString(`,"caller":{"file":"`)
-		appendJSONString(buf, entry.Caller.ShortFile)
-		buf.WriteString(`","line":`)
-		buf.WriteString(strconv.Itoa(entry.Caller.Line))
+	if f.IncludeCaller && entry.Caller.Defined && f.callerKey != "" {
+		jsonSeparator(buf, start)
+		buf.WriteString(f.callerKey)
+		buf.WriteString(f.caller.open)
+		appendCallerFile(buf, entry.Caller, enc)
+		buf.WriteString(f.caller.line)
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(entry.Caller.Line), 10))
+		buf.WriteString(f.caller.lineEnd)
 		if entry.Caller.Function != "" {
-			buf.WriteString(`,"function":"`)
-			appendJSONString(buf, entry.Caller.Function)
+			buf.WriteString(f.caller.function)
+			appendJSONString(buf, entry.Caller.Function, enc.Escape)
 			buf.WriteByte('"')
 		}
-		buf.WriteByte('}')
+		buf.WriteString(f.caller.close)
 	}
 
 	// Fields
 	for _, field := range entry.Fields {
-		buf.WriteString(`,"`)
-		appendJSONString(buf, field.Key)
+		mark := buf.Len()
+		if mark > start {
+			buf.WriteString(`,"`)
+		} else {
+			buf.WriteByte('"')
+		}
+		appendJSONString(buf, field.Key, enc.Escape)
 		buf.WriteString(`":`)
-		appendJSONFieldValue(buf, field)
+		if field.Type != core.AnyType {
+			appendJSONFieldValue(buf, field, enc)
+			continue
+		}
+		if err := appendJSONAny(buf, field.Any, enc.Escape); err != nil {
+			// Replace the field with "<key>Error" so the line stays valid JSON
+			buf.Truncate(mark)
+			jsonSeparator(buf, start)
+			buf.WriteByte('"')
+			appendJSONString(buf, field.Key, enc.Escape)
+			buf.WriteString(`Error":"`)
+			appendJSONString(buf, err.Error(), enc.Escape)
+			buf.WriteByte('"')
+		}
 	}
 
 	buf.WriteString("}\n")
 }
 
+// jsonSeparator writes a comma unless nothing was written since start
+func jsonSeparator(buf *bytes.Buffer, start int) {
+	if buf.Len() > start {
+		buf.WriteByte(',')
+	}
+}
+
+// JSONEscape selects additional escaping of JSON strings. Invalid UTF-8
+// is always replaced with U+FFFD. C1 controls (U+0080-U+009F), which
+// terminals interpret, and U+2028 and U+2029, which end lines in
+// JavaScript, are always escaped.
+type JSONEscape uint8
+
+const (
+	// JSONEscapeHTML escapes <, > and & as \u003c, \u003e and \u0026, so
+	// output can be embedded in HTML and <script> blocks
+	JSONEscapeHTML = JSONEscape(jsonstr.HTML)
+	// JSONEscapeASCII escapes every non-ASCII character as \uXXXX, using
+	// surrogate pairs above U+FFFF, for consumers that only accept ASCII
+	JSONEscapeASCII = JSONEscape(jsonstr.ASCII)
+)
+
 // appendJSONString writes a JSON-escaped string (without surrounding quotes) to the buffer
-func appendJSONString(buf *bytes.Buffer, s string) {
-	start := 0
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= 0x20 && c != '"' && c != '\\' {
-			continue
-		}
-		// Flush unescaped prefix
-		if start < i {
-			buf.WriteString(s[start:i])
-		}
-		switch c {
-		case '"':
-			buf.WriteString(`\"`)
-		case '\\':
-			buf.WriteString(`\\`)
-		case '\n':
-			buf.WriteString(`\n`)
-		case '\r':
-			buf.WriteString(`\r`)
-		case '\t':
-			buf.WriteString(`\t`)
-		default:
-			buf.WriteString(`\u00`)
-			buf.WriteByte(hexChars[c>>4])
-			buf.WriteByte(hexChars[c&0x0f])
-		}
-		start = i + 1
+func appendJSONString(buf *bytes.Buffer, s string, esc JSONEscape) {
+	jsonstr.Append(buf, s, jsonstr.Flags(esc))
+}
+
+// asciiJSONFrom escapes the non-ASCII characters of the JSON written to
+// buf since start; valid JSON only has them inside strings
+func asciiJSONFrom(buf *bytes.Buffer, start int) {
+	if !bytes.ContainsFunc(buf.Bytes()[start:], func(r rune) bool { return r >= utf8.RuneSelf }) {
+		return
 	}
-	// Flush remaining
-	if start < len(s) {
-		buf.WriteString(s[start:])
+	s := string(buf.Bytes()[start:])
+	buf.Truncate(start)
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			buf.WriteByte(byte(r))
+		} else {
+			jsonstr.AppendRune(buf, r)
+		}
 	}
 }
 
-var hexChars = [16]byte{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'a', 'b', 'c', 'd', 'e', 'f'}
-
-// appendJSONFieldValue writes a JSON-encoded field value to the buffer
-func appendJSONFieldValue(buf *bytes.Buffer, field core.Field) {
+// appendJSONFieldValue writes a JSON-encoded field value to the buffer.
+// Times use RFC3339Nano unless enc selects an epoch encoding.
+func appendJSONFieldValue(buf *bytes.Buffer, field core.Field, enc *EncoderConfig) {
 	switch field.Type {
 	case core.StringType:
 		buf.WriteByte('"')
-		appendJSONString(buf, field.Str)
+		appendJSONString(buf, field.Str, enc.Escape)
 		buf.WriteByte('"')
 	case core.IntType, core.Int64Type:
 		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), field.Int64, 10))
 	case core.Float64Type:
-		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), field.Float64, 'f', -1, 64))
+		appendJSONFloat(buf, field.Float64)
 	case core.BoolType:
 		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), field.Int64 == 1))
 	case core.TimeType:
-		buf.WriteByte('"')
-		buf.Write(time.Unix(0, field.Int64).AppendFormat(buf.AvailableBuffer(), time.RFC3339Nano))
-		buf.WriteByte('"')
+		appendTime(buf, time.Unix(0, field.Int64), enc.TimeEncoding, time.RFC3339Nano, enc.TimeUTC)
 	case core.DurationType:
-		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), field.Int64, 10))
+		appendDuration(buf, time.Duration(field.Int64), enc.DurationEncoding)
 	case core.ErrorType:
 		buf.WriteByte('"')
-		appendJSONString(buf, field.Str)
+		appendJSONString(buf, field.Str, enc.Escape)
+		buf.WriteByte('"')
+	default:
+		buf.WriteByte('"')
+		appendJSONString(buf, field.StringValue(), enc.Escape)
 		buf.WriteByte('"')
+	}
+}
+
+// appendJSONFloat writes a float. JSON has no NaN or infinities, so they
+// are written as the strings "NaN", "+Inf" and "-Inf".
+func appendJSONFloat(buf *bytes.Buffer, v float64) {
+	switch {
+	case math.IsNaN(v):
+		buf.WriteString(`"NaN"`)
+	case math.IsInf(v, 1):
+		buf.WriteString(`"+Inf"`)
+	case math.IsInf(v, -1):
+		buf.WriteString(`"-Inf"`)
 	default:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), v, 'f', -1, 64))
+	}
+}
+
+// appendJSONAny writes an AnyType value as JSON, trying json.Marshaler,
+// encoding.TextMarshaler and fmt.Stringer in that order, then error
+// messages, then encoding/json for maps, slices and structs. On error,
+// including a panic in one of those methods, the buffer may hold a
+// partial value and must be truncated by the caller.
+func appendJSONAny(buf *bytes.Buffer, v any, esc JSONEscape) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+		return nil
+	case json.Marshaler, encoding.TextMarshaler:
+		// encoding/json calls the marshalers and validates their output
+	case fmt.Stringer:
+		buf.WriteByte('"')
+		appendJSONString(buf, v.String(), esc)
+		buf.WriteByte('"')
+		return nil
+	case error:
 		buf.WriteByte('"')
-		appendJSONString(buf, field.StringValue())
+		appendJSONString(buf, v.Error(), esc)
 		buf.WriteByte('"')
+		return nil
+	}
+
+	// Encoder writes nothing on error and ends the value with a newline
+	start := buf.Len()
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(esc&JSONEscapeHTML != 0)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	if esc&JSONEscapeASCII != 0 {
+		asciiJSONFrom(buf, start)
 	}
+	return nil
 }
@@ -0,0 +1,262 @@
+package formatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/internal/jsonstr"
+)
+
+// LogfmtFormatter formats log entries as logfmt: space-separated key=value
+// pairs starting with time, level and msg. Keys and values are quoted and
+// escaped when needed, so every line can be read back with ParseLogfmt.
+type LogfmtFormatter struct {
+	Config
+}
+
+// NewLogfmtFormatter creates a new logfmt formatter
+func NewLogfmtFormatter(cfg Config) *LogfmtFormatter {
+	if cfg.TimestampFormat == "" {
+		cfg.TimestampFormat = time.RFC3339
+	}
+	return &LogfmtFormatter{Config: cfg}
+}
+
+// Format formats an entry as logfmt
+func (f *LogfmtFormatter) Format(entry *core.Entry) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	f.formatLogfmtToBuffer(entry, buf)
+
+	result := make([]byte, buf.Len())
+	copy(result, buf.Bytes())
+	return result, nil
+}
+
+// FormatTo formats an entry as logfmt and writes it directly to the writer
+func (f *LogfmtFormatter) FormatTo(entry *core.Entry, w io.Writer) error {
+	buf := getBuffer()
+
+	f.formatLogfmtToBuffer(entry, buf)
+
+	_, err := w.Write(buf.Bytes())
+	putBuffer(buf)
+	return err
+}
+
+// FormatEntry formats an entry as logfmt into the given buffer (implements BufferFormatter).
+func (f *LogfmtFormatter) FormatEntry(entry *core.Entry, buf *bytes.Buffer) {
+	f.formatLogfmtToBuffer(entry, buf)
+}
+
+// formatLogfmtToBuffer writes the entry as a logfmt line into the buffer
+func (f *LogfmtFormatter) formatLogfmtToBuffer(entry *core.Entry, buf *bytes.Buffer) {
+	buf.WriteString("time=")
+	start := buf.Len()
+	buf.Write(entry.Time.AppendFormat(buf.AvailableBuffer(), f.TimestampFormat))
+	quoteLogfmtFrom(buf, start) // A custom TimestampFormat may contain spaces
+
+	buf.WriteString(" level=")
+	if int(entry.Level) < len(lowerLevels) {
+		buf.WriteString(lowerLevels[entry.Level])
+	} else {
+		buf.WriteString("unknown")
+	}
+
+	buf.WriteString(" msg=")
+	appendLogfmtValue(buf, entry.Message)
+
+	if f.IncludeCaller && entry.Caller.Defined {
+		buf.WriteString(" caller=")
+		start = buf.Len()
+		buf.WriteString(entry.Caller.ShortFile)
+		buf.WriteByte(':')
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(entry.Caller.Line), 10))
+		quoteLogfmtFrom(buf, start)
+	}
+
+	for _, field := range entry.Fields {
+		buf.WriteByte(' ')
+		appendLogfmtKey(buf, field.Key)
+		buf.WriteByte('=')
+		appendLogfmtFieldValue(buf, field)
+	}
+
+	buf.WriteByte('\n')
+}
+
+// quoteLogfmtFrom quotes the value written to buf since start if needed
+func quoteLogfmtFrom(buf *bytes.Buffer, start int) {
+	if !bytes.ContainsAny(buf.Bytes()[start:], " \t=\"\\") {
+		return
+	}
+	value := string(buf.Bytes()[start:])
+	buf.Truncate(start)
+	appendLogfmtValue(buf, value)
+}
+
+// appendLogfmtFieldValue writes a field value, quoted if needed
+func appendLogfmtFieldValue(buf *bytes.Buffer, field core.Field) {
+	switch field.Type {
+	case core.StringType, core.ErrorType:
+		appendLogfmtValue(buf, field.Str)
+	case core.IntType, core.Int64Type:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), field.Int64, 10))
+	case core.Float64Type:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), field.Float64, 'f', -1, 64))
+	case core.BoolType:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), field.Int64 == 1))
+	case core.TimeType:
+		buf.Write(time.Unix(0, field.Int64).AppendFormat(buf.AvailableBuffer(), time.RFC3339Nano))
+	case core.DurationType:
+		buf.WriteString(time.Duration(field.Int64).String())
+	default:
+		appendLogfmtValue(buf, field.StringValue())
+	}
+}
+
+// appendLogfmtKey writes a key, quoted and escaped like a value if it
+// is empty or has characters a bare key cannot hold
+func appendLogfmtKey(buf *bytes.Buffer, key string) {
+	appendLogfmtValue(buf, key)
+}
+
+// needsLogfmtQuotes reports whether a value must be quoted: when empty or
+// containing spaces, control characters (C1 and U+2028/U+2029 included,
+// as in appendEscaped), '=', '"', '\' or invalid UTF-8.
+func needsLogfmtQuotes(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return true
+		}
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 || r < 0xa0 || r == '\u2028' || r == '\u2029' {
+				return true
+			}
+			i += size - 1
+		}
+	}
+	return false
+}
+
+// appendLogfmtValue writes s, quoted and escaped like a JSON string if
+// needed, so ParseLogfmt reads it back with strconv.Unquote
+func appendLogfmtValue(buf *bytes.Buffer, s string) {
+	if !needsLogfmtQuotes(s) {
+		buf.WriteString(s)
+		return
+	}
+	jsonstr.AppendQuoted(buf, s, 0)
+}
+
+// LogfmtPair is a key-value pair parsed from a logfmt line
+type LogfmtPair struct {
+	Key   string
+	Value string
+}
+
+// ErrLogfmtSyntax is returned by ParseLogfmt for malformed input
+var ErrLogfmtSyntax = errors.New("logfmt: syntax error")
+
+// ParseLogfmt parses one logfmt line into its key-value pairs in order.
+// A key without '=' has an empty value. Quoted keys and values are
+// unescaped.
+func ParseLogfmt(line string) ([]LogfmtPair, error) {
+	line = strings.TrimRight(line, "\r\n")
+	var pairs []LogfmtPair
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		// Key
+		var pair LogfmtPair
+		if line[i] == '"' {
+			key, n, err := unquoteLogfmt(line[i:])
+			if err != nil {
+				return pairs, fmt.Errorf("%w: %v at offset %d", ErrLogfmtSyntax, err, i)
+			}
+			pair.Key = key
+			i += n
+		} else {
+			start := i
+			for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+				i++
+			}
+			if i == start {
+				return pairs, fmt.Errorf("%w: unexpected %q at offset %d", ErrLogfmtSyntax, line[i], i)
+			}
+			pair.Key = line[start:i]
+		}
+		if i == len(line) || line[i] == ' ' || line[i] == '\t' {
+			pairs = append(pairs, pair)
+			continue
+		}
+		if line[i] != '=' {
+			return pairs, fmt.Errorf("%w: unexpected %q after key at offset %d", ErrLogfmtSyntax, line[i], i)
+		}
+		i++
+
+		// Value
+		if i < len(line) && line[i] == '"' {
+			value, n, err := unquoteLogfmt(line[i:])
+			if err != nil {
+				return pairs, fmt.Errorf("%w: %v at offset %d", ErrLogfmtSyntax, err, i)
+			}
+			pair.Value = value
+			i += n
+			if i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				return pairs, fmt.Errorf("%w: unexpected %q after quoted value at offset %d", ErrLogfmtSyntax, line[i], i)
+			}
+		} else {
+			start := i
+			for i < len(line) && line[i] > ' ' {
+				if line[i] == '"' || line[i] == '=' {
+					return pairs, fmt.Errorf("%w: unexpected %q in unquoted value at offset %d", ErrLogfmtSyntax, line[i], i)
+				}
+				i++
+			}
+			pair.Value = line[start:i]
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// unquoteLogfmt unquotes the quoted value at the start of s and returns it
+// with the number of bytes consumed.
+func unquoteLogfmt(s string) (string, int, error) {
+	// Find the closing quote, skipping escaped characters
+	end := 1
+	for ; end < len(s); end++ {
+		if s[end] == '\\' {
+			end++
+			continue
+		}
+		if s[end] == '"' {
+			break
+		}
+	}
+	if end >= len(s) {
+		return "", 0, errors.New("unterminated quoted value")
+	}
+	value, err := strconv.Unquote(s[:end+1])
+	if err != nil {
+		return "", 0, err
+	}
+	return value, end + 1, nil
+}
@@ -0,0 +1,162 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// MsgpackFormatter formats log entries as MessagePack maps with the keys
+// and field semantics of the JSONFormatter. Times use the timestamp
+// extension type, durations integer nanoseconds in extension type 1. Use
+// the decoder package to read the stream back.
+type MsgpackFormatter struct {
+	Config
+}
+
+// NewMsgpackFormatter creates a new MessagePack formatter
+func NewMsgpackFormatter(cfg Config) *MsgpackFormatter {
+	return &MsgpackFormatter{Config: cfg}
+}
+
+// Format formats an entry as MessagePack
+func (f *MsgpackFormatter) Format(entry *core.Entry) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	formatBinaryToBuffer(msgpackEncoding{}, &f.Config, entry, buf)
+
+	result := make([]byte, buf.Len())
+	copy(result, buf.Bytes())
+	return result, nil
+}
+
+// FormatTo formats an entry as MessagePack and writes it directly to the writer
+func (f *MsgpackFormatter) FormatTo(entry *core.Entry, w io.Writer) error {
+	buf := getBuffer()
+
+	formatBinaryToBuffer(msgpackEncoding{}, &f.Config, entry, buf)
+
+	_, err := w.Write(buf.Bytes())
+	putBuffer(buf)
+	return err
+}
+
+// FormatEntry formats an entry as MessagePack into the given buffer (implements BufferFormatter).
+func (f *MsgpackFormatter) FormatEntry(entry *core.Entry, buf *bytes.Buffer) {
+	formatBinaryToBuffer(msgpackEncoding{}, &f.Config, entry, buf)
+}
+
+// msgpackEncoding writes MessagePack values in their shortest form
+type msgpackEncoding struct{}
+
+func (msgpackEncoding) appendMapHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xde)
+		buf.Write(binary.BigEndian.AppendUint16(buf.AvailableBuffer(), uint16(n)))
+	default:
+		buf.WriteByte(0xdf)
+		buf.Write(binary.BigEndian.AppendUint32(buf.AvailableBuffer(), uint32(n)))
+	}
+}
+
+func (msgpackEncoding) appendArrayHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xdc)
+		buf.Write(binary.BigEndian.AppendUint16(buf.AvailableBuffer(), uint16(n)))
+	default:
+		buf.WriteByte(0xdd)
+		buf.Write(binary.BigEndian.AppendUint32(buf.AvailableBuffer(), uint32(n)))
+	}
+}
+
+func (msgpackEncoding) appendNil(buf *bytes.Buffer) {
+	buf.WriteByte(0xc0)
+}
+
+func (msgpackEncoding) appendString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		buf.Write(binary.BigEndian.AppendUint16(buf.AvailableBuffer(), uint16(n)))
+	default:
+		buf.WriteByte(0xdb)
+		buf.Write(binary.BigEndian.AppendUint32(buf.AvailableBuffer(), uint32(n)))
+	}
+	buf.WriteString(s)
+}
+
+func (msgpackEncoding) appendInt(buf *bytes.Buffer, v int64) {
+	switch {
+	case v >= 0 && v <= 127, v >= -32 && v < 0:
+		buf.WriteByte(byte(v)) // positive or negative fixint
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(v))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(buf.AvailableBuffer(), uint16(v)))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(buf.AvailableBuffer(), uint32(v)))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(buf.AvailableBuffer(), uint64(v)))
+	}
+}
+
+func (msgpackEncoding) appendFloat(buf *bytes.Buffer, v float64) {
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(buf.AvailableBuffer(), math.Float64bits(v)))
+}
+
+func (msgpackEncoding) appendBool(buf *bytes.Buffer, v bool) {
+	if v {
+		buf.WriteByte(0xc3)
+	} else {
+		buf.WriteByte(0xc2)
+	}
+}
+
+// appendTime writes the timestamp extension (type -1): 64-bit when the
+// seconds fit in 34 bits, 96-bit otherwise
+func (msgpackEncoding) appendTime(buf *bytes.Buffer, t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	if sec >= 0 && sec < 1<<34 {
+		buf.WriteByte(0xd7) // fixext 8
+		buf.WriteByte(0xff)
+		buf.Write(binary.BigEndian.AppendUint64(buf.AvailableBuffer(), nsec<<34|uint64(sec)))
+		return
+	}
+	buf.WriteByte(0xc7) // ext 8
+	buf.WriteByte(12)
+	buf.WriteByte(0xff)
+	buf.Write(binary.BigEndian.AppendUint32(buf.AvailableBuffer(), uint32(nsec)))
+	buf.Write(binary.BigEndian.AppendUint64(buf.AvailableBuffer(), uint64(sec)))
+}
+
+// msgpackDurationExt is the extension type of durations, 8 bytes of
+// big-endian signed nanoseconds
+const msgpackDurationExt = 1
+
+func (msgpackEncoding) appendDuration(buf *bytes.Buffer, d time.Duration) {
+	buf.WriteByte(0xd7) // fixext 8
+	buf.WriteByte(msgpackDurationExt)
+	buf.Write(binary.BigEndian.AppendUint64(buf.AvailableBuffer(), uint64(d)))
+}
@@ -0,0 +1,110 @@
+package formatter
+
+import "github.com/philipp01105/nlog/core"
+
+// ECSVersion is the Elastic Common Schema version written by the ECS preset
+const ECSVersion = "8.11.0"
+
+// gcpSeverities are the LogSeverity names of Google Cloud Logging
+var gcpSeverities = [6]string{
+	core.DebugLevel: "DEBUG",
+	core.InfoLevel:  "INFO",
+	core.WarnLevel:  "WARNING",
+	core.ErrorLevel: "ERROR",
+	core.FatalLevel: "CRITICAL",
+	core.PanicLevel: "ALERT",
+}
+
+// datadogStatuses are the Datadog log status names
+var datadogStatuses = [6]string{
+	core.DebugLevel: "debug",
+	core.InfoLevel:  "info",
+	core.WarnLevel:  "warning",
+	core.ErrorLevel: "error",
+	core.FatalLevel: "critical",
+	core.PanicLevel: "alert",
+}
+
+// ecsCallerLayout writes the ECS log.origin object:
+// {"file":{"name":"..","line":N},"function":".."}
+var ecsCallerLayout = &callerLayout{
+	open:     `{"file":{"name":"`,
+	line:     `","line":`,
+	lineEnd:  `}`,
+	function: `,"function":"`,
+	close:    `}`,
+}
+
+// gcpCallerLayout writes a LogEntrySourceLocation, whose line is an int64
+// and therefore a string in JSON: {"file":"..","line":"N","function":".."}
+var gcpCallerLayout = &callerLayout{
+	open:     `{"file":"`,
+	line:     `","line":"`,
+	lineEnd:  `"`,
+	function: `,"function":"`,
+	close:    `}`,
+}
+
+// datadogCallerLayout writes the function as the logger.method_name
+// standard attribute. Datadog defines no attributes for the file and line
+// and reserves the rest of logger for the logger name, thread and version
+// (https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/#source-code),
+// so they stay in the caller object:
+// {"file":"..","line":N},"logger":{"method_name":".."}
+var datadogCallerLayout = &callerLayout{
+	open:     `{"file":"`,
+	line:     `","line":`,
+	function: `},"logger":{"method_name":"`,
+	close:    `}`,
+}
+
+// NewECSFormatter creates a JSON formatter for the Elastic Common Schema:
+// "@timestamp" in UTC, "log.level", "message", "ecs.version" and the caller
+// under "log.origin". Only the key names, level names and time zone of
+// cfg.Encoder are overridden.
+func NewECSFormatter(cfg Config) *JSONFormatter {
+	cfg.Encoder.TimeKey = "@timestamp"
+	cfg.Encoder.LevelKey = "log.level"
+	cfg.Encoder.MessageKey = "message"
+	cfg.Encoder.CallerKey = "log.origin"
+	cfg.Encoder.TimeUTC = true
+	f := NewJSONFormatter(cfg)
+	f.levelNames = &lowerLevels
+	f.caller = ecsCallerLayout
+	f.static = `"ecs.version":"` + ECSVersion + `"`
+	return f
+}
+
+// NewGCPFormatter creates a JSON formatter for Google Cloud Logging
+// structured logs: "time" in UTC, "severity" with LogSeverity names
+// (Fatal is CRITICAL, Panic is ALERT), "message" and the caller as
+// "logging.googleapis.com/sourceLocation". Only the key names, level
+// names and time zone of cfg.Encoder are overridden.
+func NewGCPFormatter(cfg Config) *JSONFormatter {
+	cfg.Encoder.TimeKey = "time"
+	cfg.Encoder.LevelKey = "severity"
+	cfg.Encoder.MessageKey = "message"
+	cfg.Encoder.CallerKey = "logging.googleapis.com/sourceLocation"
+	cfg.Encoder.TimeUTC = true
+	f := NewJSONFormatter(cfg)
+	f.levelNames = &gcpSeverities
+	f.caller = gcpCallerLayout
+	return f
+}
+
+// NewDatadogFormatter creates a JSON formatter for Datadog log
+// management: "timestamp" in UTC, "status" with Datadog status names
+// (Fatal is critical, Panic is alert), "message", the caller file and
+// line under "caller" and the function as "logger.method_name". Only the
+// key names, level names and time zone of cfg.Encoder are overridden.
+func NewDatadogFormatter(cfg Config) *JSONFormatter {
+	cfg.Encoder.TimeKey = "timestamp"
+	cfg.Encoder.LevelKey = "status"
+	cfg.Encoder.MessageKey = "message"
+	cfg.Encoder.CallerKey = "caller"
+	cfg.Encoder.TimeUTC = true
+	f := NewJSONFormatter(cfg)
+	f.levelNames = &datadogStatuses
+	f.caller = datadogCallerLayout
+	return f
+}
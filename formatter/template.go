@@ -0,0 +1,375 @@
+package formatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// DefaultTemplate is the pattern used when TemplateConfig.Pattern is empty
+const DefaultTemplate = "%time [%level] %msg %fields"
+
+// ErrTemplateSyntax is returned by NewTemplateFormatter for a malformed pattern
+var ErrTemplateSyntax = errors.New("template: syntax error")
+
+// maxTemplateWidth caps padding and truncation widths in a pattern
+const maxTemplateWidth = 1024
+
+// TemplateConfig holds configuration for the template formatter
+type TemplateConfig struct {
+	Config
+	// Pattern is the layout of each line (default: DefaultTemplate)
+	Pattern string
+}
+
+// TemplateFormatter formats log entries with a log4j-style pattern such as
+//
+//	%time{15:04:05.000} %-5level [%caller] %msg %fields
+//
+// Placeholders:
+//
+//	%time, %time{layout}  entry time in TimestampFormat or the given layout
+//	%level                level name, e.g. INFO
+//	%msg, %message        message
+//	%caller               caller as file:line
+//	%file, %line, %func   caller file name, line and function
+//	%fields               fields as key=value pairs separated by spaces
+//	%field{key}           value of the field named key, empty if missing
+//	%n                    newline
+//	%%                    a literal '%'
+//
+// A width between '%' and the name pads the value to that many characters,
+// on the left by default or on the right with '-'; ".N" truncates it to N
+// characters, e.g. %-5level, %10.10msg or %.8field{request_id}. Fields
+// shown with %field are left out of %fields, and a space before %fields is
+// only written when there are fields. Every line ends with a newline.
+//
+// The pattern is compiled once into a sequence of append operations, so
+// formatting never parses it again.
+type TemplateFormatter struct {
+	TemplateConfig
+	ops     []templateOp
+	exclude []string // keys shown with %field, omitted from %fields
+}
+
+// templateOp appends one part of the line
+type templateOp func(f *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer)
+
+// NewTemplateFormatter compiles the pattern and creates a template formatter
+func NewTemplateFormatter(cfg TemplateConfig) (*TemplateFormatter, error) {
+	if cfg.Pattern == "" {
+		cfg.Pattern = DefaultTemplate
+	}
+	if cfg.TimestampFormat == "" {
+		cfg.TimestampFormat = time.RFC3339
+	}
+	if cfg.Indent == "" {
+		cfg.Indent = DefaultIndent
+	}
+	f := &TemplateFormatter{TemplateConfig: cfg}
+	if err := f.compile(cfg.Pattern); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Format formats an entry with the pattern
+func (f *TemplateFormatter) Format(entry *core.Entry) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	f.formatTemplateToBuffer(entry, buf)
+
+	result := make([]byte, buf.Len())
+	copy(result, buf.Bytes())
+	return result, nil
+}
+
+// FormatTo formats an entry with the pattern and writes it directly to the writer
+func (f *TemplateFormatter) FormatTo(entry *core.Entry, w io.Writer) error {
+	buf := getBuffer()
+
+	f.formatTemplateToBuffer(entry, buf)
+
+	_, err := w.Write(buf.Bytes())
+	putBuffer(buf)
+	return err
+}
+
+// FormatEntry formats an entry into the given buffer (implements BufferFormatter).
+func (f *TemplateFormatter) FormatEntry(entry *core.Entry, buf *bytes.Buffer) {
+	f.formatTemplateToBuffer(entry, buf)
+}
+
+// formatTemplateToBuffer runs the compiled operations
+func (f *TemplateFormatter) formatTemplateToBuffer(entry *core.Entry, buf *bytes.Buffer) {
+	for _, op := range f.ops {
+		op(f, entry, buf)
+	}
+	buf.WriteByte('\n')
+}
+
+// compile turns the pattern into operations
+func (f *TemplateFormatter) compile(pattern string) error {
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			f.ops = append(f.ops, literalOp(lit.String()))
+			lit.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		if c != '%' {
+			lit.WriteByte(c)
+			i++
+			continue
+		}
+		i++
+		if i < len(pattern) && pattern[i] == '%' {
+			lit.WriteByte('%')
+			i++
+			continue
+		}
+
+		// Padding and truncation: [-][width][.max]
+		start := i - 1
+		left := false
+		if i < len(pattern) && pattern[i] == '-' {
+			left = true
+			i++
+		}
+		width, n := parseTemplateInt(pattern[i:])
+		i += n
+		limit := 0
+		if i < len(pattern) && pattern[i] == '.' {
+			i++
+			limit, n = parseTemplateInt(pattern[i:])
+			if n == 0 {
+				return fmt.Errorf("%w: missing length after '.' at offset %d in %q", ErrTemplateSyntax, i, pattern)
+			}
+			i += n
+		}
+		if width > maxTemplateWidth || limit > maxTemplateWidth {
+			return fmt.Errorf("%w: width over %d at offset %d in %q", ErrTemplateSyntax, maxTemplateWidth, start, pattern)
+		}
+
+		// Name and optional {argument}
+		nameStart := i
+		for i < len(pattern) && isTemplateNameByte(pattern[i]) {
+			i++
+		}
+		name := pattern[nameStart:i]
+		arg, hasArg := "", false
+		if i < len(pattern) && pattern[i] == '{' {
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return fmt.Errorf("%w: unterminated '{' at offset %d in %q", ErrTemplateSyntax, i, pattern)
+			}
+			arg, hasArg = pattern[i+1:i+end], true
+			i += end + 1
+		}
+
+		var op templateOp
+		switch name {
+		case "time":
+			op = timeOp(arg)
+		case "level":
+			op = appendTemplateLevel
+		case "msg", "message":
+			op = appendTemplateMessage
+		case "caller":
+			op = appendTemplateCaller
+		case "file":
+			op = appendTemplateFile
+		case "line":
+			op = appendTemplateLine
+		case "func":
+			op = appendTemplateFunc
+		case "fields":
+			// Move a separating space into the operation, so it is only
+			// written when there are fields
+			prefix := ""
+			if s := lit.String(); strings.HasSuffix(s, " ") {
+				lit.Reset()
+				lit.WriteString(s[:len(s)-1])
+				prefix = " "
+			}
+			op = fieldsOp(prefix)
+		case "field":
+			if arg == "" {
+				return fmt.Errorf("%w: %%field needs a key, e.g. %%field{request_id}, at offset %d in %q", ErrTemplateSyntax, start, pattern)
+			}
+			f.exclude = append(f.exclude, arg)
+			op = fieldOp(arg)
+		case "n":
+			op = literalOp("\n")
+		case "":
+			return fmt.Errorf("%w: missing placeholder name at offset %d in %q", ErrTemplateSyntax, start, pattern)
+		default:
+			return fmt.Errorf("%w: unknown placeholder %%%s at offset %d in %q", ErrTemplateSyntax, name, start, pattern)
+		}
+		if hasArg && name != "time" && name != "field" {
+			return fmt.Errorf("%w: %%%s takes no argument at offset %d in %q", ErrTemplateSyntax, name, start, pattern)
+		}
+		if width > 0 || limit > 0 {
+			op = paddedOp(op, width, left, limit)
+		}
+		flush()
+		f.ops = append(f.ops, op)
+	}
+	flush()
+	return nil
+}
+
+// parseTemplateInt parses leading decimal digits and returns the value
+// and the number of bytes consumed
+func parseTemplateInt(s string) (int, int) {
+	v, n := 0, 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' && v <= maxTemplateWidth {
+		v = v*10 + int(s[n]-'0')
+		n++
+	}
+	return v, n
+}
+
+// isTemplateNameByte reports whether c can appear in a placeholder name
+func isTemplateNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// literalOp writes fixed text
+func literalOp(s string) templateOp {
+	return func(_ *TemplateFormatter, _ *core.Entry, buf *bytes.Buffer) {
+		buf.WriteString(s)
+	}
+}
+
+// timeOp writes the entry time in layout, or TimestampFormat if empty
+func timeOp(layout string) templateOp {
+	return func(f *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+		l := layout
+		if l == "" {
+			l = f.TimestampFormat
+		}
+		buf.Write(entry.Time.AppendFormat(buf.AvailableBuffer(), l))
+	}
+}
+
+func appendTemplateLevel(_ *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+	buf.WriteString(entry.Level.String())
+}
+
+func appendTemplateMessage(f *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+	appendEscaped(buf, entry.Message, f.Escape, f.Indent)
+}
+
+func appendTemplateCaller(_ *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+	if entry.Caller.Defined {
+		buf.WriteString(entry.Caller.ShortFile)
+		buf.WriteByte(':')
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(entry.Caller.Line), 10))
+	}
+}
+
+func appendTemplateFile(_ *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+	buf.WriteString(entry.Caller.ShortFile)
+}
+
+func appendTemplateLine(_ *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+	if entry.Caller.Defined {
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(entry.Caller.Line), 10))
+	}
+}
+
+func appendTemplateFunc(_ *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+	buf.WriteString(entry.Caller.Function)
+}
+
+// fieldsOp writes the fields not shown with %field, preceded by prefix
+func fieldsOp(prefix string) templateOp {
+	return func(f *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+		first := true
+		for _, field := range entry.Fields {
+			if f.excluded(field.Key) {
+				continue
+			}
+			if first {
+				buf.WriteString(prefix)
+				first = false
+			} else {
+				buf.WriteByte(' ')
+			}
+			appendTextKey(buf, field.Key, f.Escape)
+			buf.WriteByte('=')
+			appendTextFieldValue(buf, field, f.Escape, f.Indent)
+		}
+	}
+}
+
+// fieldOp writes the value of the last field named key
+func fieldOp(key string) templateOp {
+	return func(f *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+		for i := len(entry.Fields) - 1; i >= 0; i-- {
+			if entry.Fields[i].Key == key {
+				appendTextFieldValue(buf, entry.Fields[i], f.Escape, f.Indent)
+				return
+			}
+		}
+	}
+}
+
+// excluded reports whether key is shown with %field
+func (f *TemplateFormatter) excluded(key string) bool {
+	for _, k := range f.exclude {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// paddedOp truncates the output of op to limit characters (0 = no limit)
+// and pads it with spaces to width, on the right if left is set.
+func paddedOp(op templateOp, width int, left bool, limit int) templateOp {
+	return func(f *TemplateFormatter, entry *core.Entry, buf *bytes.Buffer) {
+		start := buf.Len()
+		op(f, entry, buf)
+		b := buf.Bytes()[start:]
+
+		n := 0
+		for i := 0; i < len(b); n++ {
+			if n == limit && limit > 0 {
+				buf.Truncate(start + i)
+				b = b[:i]
+				break
+			}
+			_, size := utf8.DecodeRune(b[i:])
+			i += size
+		}
+		if n >= width {
+			return
+		}
+
+		p := width - n
+		for rest := p; rest > 0; rest -= len(spaces) {
+			buf.WriteString(spaces[:min(rest, len(spaces))])
+		}
+		if !left {
+			// Right-align by shifting the value behind the padding
+			b = buf.Bytes()[start:]
+			copy(b[p:], b[:len(b)-p])
+			for i := range p {
+				b[i] = ' '
+			}
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 // TextFormatter formats log entries as human-readable text
 type TextFormatter struct {
 	Config
+	color bool
 }
 
 // NewTextFormatter creates a new text formatter
@@ -20,7 +21,21 @@ func NewTextFormatter(cfg Config) *TextFormatter {
 	if cfg.TimestampFormat == "" {
 		cfg.TimestampFormat = time.RFC3339
 	}
-	return &TextFormatter{Config: cfg}
+	if cfg.Indent == "" {
+		cfg.Indent = DefaultIndent
+	}
+	return &TextFormatter{Config: cfg, color: cfg.Color == ColorAlways}
+}
+
+// ForWriter resolves ColorAuto for w (implements WriterAwareFormatter).
+// Without ColorAuto the formatter itself is returned.
+func (f *TextFormatter) ForWriter(w io.Writer) Formatter {
+	if f.Color != ColorAuto {
+		return f
+	}
+	c := *f
+	c.color = ColorEnabled(w)
+	return &c
 }
 
 // Format formats an entry as text
@@ -62,8 +77,22 @@ var levelBrackets = [...]string{
 	core.PanicLevel: " [PANIC] ",
 }
 
+// colorLevelBrackets are levelBrackets with the level name colored
+var colorLevelBrackets = func() (brackets [len(levelBrackets)]string) {
+	for level, s := range levelBrackets {
+		name := s[2 : len(s)-2]
+		brackets[level] = " [" + levelColors[level] + name + ansiReset + "] "
+	}
+	return brackets
+}()
+
 // formatToBuffer writes the formatted entry into the given buffer
 func (f *TextFormatter) formatToBuffer(entry *core.Entry, buf *bytes.Buffer) {
+	if f.color {
+		f.formatColorToBuffer(entry, buf)
+		return
+	}
+
 	// Timestamp - use AppendFormat to avoid string allocation
 	buf.Write(entry.Time.AppendFormat(buf.AvailableBuffer(), f.TimestampFormat))
 
@@ -84,24 +113,72 @@ func (f *TextFormatter) formatToBuffer(entry *core.Entry, buf *bytes.Buffer) {
 	}
 
 	// Message
-	buf.WriteString(entry.Message)
+	appendEscaped(buf, entry.Message, f.Escape, f.Indent)
 
 	// Fields - write values directly to buffer to avoid intermediate string allocations
 	for _, field := range entry.Fields {
 		buf.WriteByte(' ')
-		buf.WriteString(field.Key)
+		appendTextKey(buf, field.Key, f.Escape)
 		buf.WriteByte('=')
-		appendTextFieldValue(buf, field)
+		appendTextFieldValue(buf, field, f.Escape, f.Indent)
+	}
+
+	buf.WriteByte('\n')
+}
+
+// formatColorToBuffer is formatToBuffer with ANSI colors: a faint
+// timestamp, colored level, cyan keys and red error values.
+func (f *TextFormatter) formatColorToBuffer(entry *core.Entry, buf *bytes.Buffer) {
+	buf.WriteString(ansiFaint)
+	buf.Write(entry.Time.AppendFormat(buf.AvailableBuffer(), f.TimestampFormat))
+	buf.WriteString(ansiReset)
+
+	if int(entry.Level) < len(colorLevelBrackets) {
+		buf.WriteString(colorLevelBrackets[entry.Level])
+	} else {
+		buf.WriteString(" [UNKNOWN] ")
+	}
+
+	if f.IncludeCaller && entry.Caller.Defined {
+		buf.WriteString(ansiFaint + "[")
+		buf.WriteString(entry.Caller.ShortFile)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(entry.Caller.Line))
+		buf.WriteString("]" + ansiReset + " ")
+	}
+
+	appendEscaped(buf, entry.Message, f.Escape, f.Indent)
+
+	for _, field := range entry.Fields {
+		buf.WriteString(" " + ansiCyan)
+		appendTextKey(buf, field.Key, f.Escape)
+		buf.WriteString(ansiReset + "=")
+		if field.Type == core.ErrorType {
+			buf.WriteString(ansiRed)
+			appendTextFieldValue(buf, field, f.Escape, f.Indent)
+			buf.WriteString(ansiReset)
+		} else {
+			appendTextFieldValue(buf, field, f.Escape, f.Indent)
+		}
 	}
 
 	buf.WriteByte('\n')
 }
 
+// appendTextKey writes a field key; keys never span lines, so EscapeIndent
+// escapes like EscapeControl
+func appendTextKey(buf *bytes.Buffer, key string, mode EscapeMode) {
+	if mode == EscapeIndent {
+		mode = EscapeControl
+	}
+	appendEscaped(buf, key, mode, "")
+}
+
 // appendTextFieldValue writes a field value directly to the buffer without intermediate string allocation
-func appendTextFieldValue(buf *bytes.Buffer, field core.Field) {
+func appendTextFieldValue(buf *bytes.Buffer, field core.Field, mode EscapeMode, indent string) {
 	switch field.Type {
 	case core.StringType:
-		buf.WriteString(field.Str)
+		appendEscaped(buf, field.Str, mode, indent)
 	case core.IntType, core.Int64Type:
 		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), field.Int64, 10))
 	case core.Float64Type:
@@ -113,10 +190,14 @@ func appendTextFieldValue(buf *bytes.Buffer, field core.Field) {
 	case core.DurationType:
 		buf.WriteString(time.Duration(field.Int64).String())
 	case core.ErrorType:
-		buf.WriteString(field.Str)
+		appendEscaped(buf, field.Str, mode, indent)
 	case core.AnyType:
-		fmt.Fprint(buf, field.Any)
+		if mode == EscapeNone {
+			fmt.Fprint(buf, field.Any)
+		} else {
+			appendEscaped(buf, fmt.Sprint(field.Any), mode, indent)
+		}
 	default:
-		buf.WriteString(field.StringValue())
+		appendEscaped(buf, field.StringValue(), mode, indent)
 	}
 }
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BlockResult reports how a blocking enqueue finished.
+type BlockResult int
+
+const (
+	// BlockEnqueued means the entry was placed on the queue
+	BlockEnqueued BlockResult = iota
+	// BlockTimedOut means the block timeout elapsed while the queue was full
+	BlockTimedOut
+	// BlockCanceled means the caller's context was done before space was available
+	BlockCanceled
+	// BlockClosed means the handler was closed while the caller was waiting
+	BlockClosed
+)
+
+// String returns the string representation of the result
+func (r BlockResult) String() string {
+	switch r {
+	case BlockEnqueued:
+		return "Enqueued"
+	case BlockTimedOut:
+		return "TimedOut"
+	case BlockCanceled:
+		return "Canceled"
+	case BlockClosed:
+		return "Closed"
+	default:
+		return "Unknown"
+	}
+}
+
+// blockTimerPool holds stopped timers for blocked producers. Every blocked
+// caller takes its own timer, so concurrent producers never share timer
+// state, while the steady state stays allocation-free.
+var blockTimerPool = sync.Pool{
+	New: func() interface{} {
+		return NewStoppedTimer()
+	},
+}
+
+// EnqueueBlock sends item, usually an entry, to queue, waiting while the
+// queue is full.
+// The wait ends when space becomes available, when timeout elapses, when
+// ctx is done (ctx may be nil) or when closed is closed, whichever comes
+// first. It is safe for any number of concurrent callers.
+//
+// A handler that closes its queue channel must prevent EnqueueBlock from
+// running concurrently with that close, e.g. by holding a read lock
+// around the call.
+func EnqueueBlock[T any](ctx context.Context, queue chan<- T, item T, timeout time.Duration, closed <-chan struct{}) BlockResult {
+	select {
+	case <-closed:
+		return BlockClosed
+	default:
+	}
+
+	// Fast path: space available
+	select {
+	case queue <- item:
+		return BlockEnqueued
+	default:
+	}
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	t := blockTimerPool.Get().(*time.Timer)
+	t.Reset(timeout)
+
+	var res BlockResult
+	select {
+	case queue <- item:
+		res = BlockEnqueued
+	case <-t.C:
+		res = BlockTimedOut
+	case <-done:
+		res = BlockCanceled
+	case <-closed:
+		res = BlockClosed
+	}
+
+	// Since Go 1.23, Stop guarantees no stale value is received after
+	// a later Reset, so the timer can go straight back to the pool.
+	t.Stop()
+	blockTimerPool.Put(t)
+	return res
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+func TestEnqueueBlock_Results(t *testing.T) {
+	entry := &core.Entry{}
+
+	t.Run("Enqueued", func(t *testing.T) {
+		queue := make(chan *core.Entry, 1)
+		closed := make(chan struct{})
+		if res := EnqueueBlock(context.Background(), queue, entry, time.Second, closed); res != BlockEnqueued {
+			t.Errorf("EnqueueBlock() = %v, want %v", res, BlockEnqueued)
+		}
+	})
+
+	t.Run("TimedOut", func(t *testing.T) {
+		queue := make(chan *core.Entry)
+		closed := make(chan struct{})
+		if res := EnqueueBlock(context.Background(), queue, entry, 10*time.Millisecond, closed); res != BlockTimedOut {
+			t.Errorf("EnqueueBlock() = %v, want %v", res, BlockTimedOut)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		queue := make(chan *core.Entry)
+		closed := make(chan struct{})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if res := EnqueueBlock(ctx, queue, entry, 10*time.Second, closed); res != BlockCanceled {
+			t.Errorf("EnqueueBlock() = %v, want %v", res, BlockCanceled)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("context deadline not honoured, waited %v", elapsed)
+		}
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		queue := make(chan *core.Entry)
+		closed := make(chan struct{})
+		close(closed)
+		if res := EnqueueBlock(nil, queue, entry, time.Second, closed); res != BlockClosed {
+			t.Errorf("EnqueueBlock() = %v, want %v", res, BlockClosed)
+		}
+	})
+}
+
+func TestEnqueueBlock_ConcurrentProducers(t *testing.T) {
+	const producers = 32
+	const perProducer = 20
+
+	queue := make(chan *core.Entry, 1)
+	closed := make(chan struct{})
+
+	var received sync.WaitGroup
+	received.Add(1)
+	count := 0
+	go func() {
+		defer received.Done()
+		for range queue {
+			count++
+			time.Sleep(10 * time.Microsecond)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				if res := EnqueueBlock(context.Background(), queue, &core.Entry{}, 5*time.Second, closed); res != BlockEnqueued {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(queue)
+	received.Wait()
+
+	if failed != 0 {
+		t.Errorf("%d producers failed to enqueue", failed)
+	}
+	if count != producers*perProducer {
+		t.Errorf("received %d entries, want %d", count, producers*perProducer)
+	}
+}
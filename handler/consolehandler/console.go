@@ -166,6 +166,28 @@ type ConsoleConfig struct {
 	BlockTimeout time.Duration
 	// DrainTimeout is the timeout for draining queue on Close (default: 5s)
 	DrainTimeout time.Duration
+	// StrictOrdering makes the Block policy drop entries that time out
+	// (or whose context is done) instead of writing them synchronously
+	// from the caller, so entries never bypass the queue and are always
+	// written in enqueue order. Dropped entries are counted in both
+	// BlockedTotal and DroppedTotal.
+	StrictOrdering bool
+	// ErrorHandler is called from the background goroutine for every
+	// write error of an async handler (default: none)
+	ErrorHandler handler.ErrorHandler
+	// Fallback receives entries while an async handler's output keeps
+	// failing, e.g. a console handler on os.Stderr (default: none, entries
+	// are dropped)
+	Fallback handler.Handler
+	// MaxRetries is the number of retries for a transient write error
+	// before falling back (default: 3, negative disables retries)
+	MaxRetries int
+	// RetryBackoff is the initial delay between retries, doubled per
+	// attempt (default: 10ms)
+	RetryBackoff time.Duration
+	// MaxRetryBackoff caps the retry delay and the interval at which a
+	// failing output is probed for recovery (default: 5s)
+	MaxRetryBackoff time.Duration
 	// ConcurrentWriter indicates the Writer supports concurrent Write calls.
 	// When true, the handler skips write-level locking for parallel log entries,
 	// significantly improving parallel throughput. Automatically detected for
@@ -181,6 +203,10 @@ func applyConsoleDefaults(cfg *ConsoleConfig) {
 	if cfg.Formatter == nil {
 		cfg.Formatter = formatter.NewTextFormatter(formatter.Config{})
 	}
+	// Resolve writer-dependent options such as ColorAuto
+	if wa, ok := cfg.Formatter.(formatter.WriterAwareFormatter); ok {
+		cfg.Formatter = wa.ForWriter(cfg.Writer)
+	}
 	if cfg.BufferSize <= 0 {
 		cfg.BufferSize = 1000
 	}
@@ -193,6 +219,20 @@ func applyConsoleDefaults(cfg *ConsoleConfig) {
 	if cfg.DrainTimeout == 0 {
 		cfg.DrainTimeout = 5 * time.Second
 	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 10 * time.Millisecond
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = 5 * time.Second
+	}
+	if cfg.MaxRetryBackoff < cfg.RetryBackoff {
+		cfg.MaxRetryBackoff = cfg.RetryBackoff
+	}
 }
 
 // NewConsoleHandler creates a new console handler.
@@ -1,6 +1,7 @@
 package consolehandler
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -19,8 +20,10 @@ type AsyncConsoleHandler struct {
 	overflowPolicy map[core.Level]handler.OverflowPolicy
 	blockTimeout   time.Duration
 	drainTimeout   time.Duration
-	blockTimer     *time.Timer
-	parBufPool     sync.Pool // pool of *parallelBuf for overflow fallback writes
+	strictOrdering bool
+	closeMu        sync.RWMutex // held shared by blocked producers, exclusively by Close
+	parBufPool     sync.Pool    // pool of *parallelBuf for overflow fallback writes
+	supervisor     *handler.Supervisor
 }
 
 // newAsyncConsoleHandler creates a new asynchronous console handler.
@@ -29,7 +32,7 @@ func newAsyncConsoleHandler(cfg ConsoleConfig) *AsyncConsoleHandler {
 		overflowPolicy: cfg.OverflowPolicy,
 		blockTimeout:   cfg.BlockTimeout,
 		drainTimeout:   cfg.DrainTimeout,
-		blockTimer:     handler.NewStoppedTimer(),
+		strictOrdering: cfg.StrictOrdering,
 	}
 	h.writer = cfg.Writer
 	h.formatter = cfg.Formatter
@@ -59,6 +62,19 @@ func newAsyncConsoleHandler(cfg ConsoleConfig) *AsyncConsoleHandler {
 		}
 	}
 
+	h.supervisor = handler.NewSupervisor(handler.SupervisorConfig{
+		Write: func(entry *core.Entry) error {
+			return h.processWrite(entry, &h.parBufPool)
+		},
+		Fallback:        cfg.Fallback,
+		ErrorHandler:    cfg.ErrorHandler,
+		MaxRetries:      cfg.MaxRetries,
+		RetryBackoff:    cfg.RetryBackoff,
+		MaxRetryBackoff: cfg.MaxRetryBackoff,
+		Stats:           h.stats,
+		Closed:          h.closed,
+	})
+
 	h.queue = make(chan *core.Entry, cfg.BufferSize)
 	h.wg.Add(1)
 	go h.process()
@@ -85,6 +101,13 @@ func (h *AsyncConsoleHandler) HandleLog(t time.Time, level core.Level, msg strin
 
 // Handle sends a log entry to the async queue with overflow policy handling.
 func (h *AsyncConsoleHandler) Handle(entry *core.Entry) error {
+	return h.HandleContext(context.Background(), entry)
+}
+
+// HandleContext sends a log entry to the async queue with overflow policy
+// handling. Under the Block policy the wait is additionally bounded by
+// the deadline and cancellation of ctx.
+func (h *AsyncConsoleHandler) HandleContext(ctx context.Context, entry *core.Entry) error {
 	// Get overflow policy for this level
 	policy, ok := h.overflowPolicy[entry.Level]
 	if !ok {
@@ -93,43 +116,27 @@ func (h *AsyncConsoleHandler) Handle(entry *core.Entry) error {
 
 	switch policy {
 	case handler.Block:
-		// Try to send with timeout using reusable timer
-		select {
-		case h.queue <- entry:
+		// Shared lock keeps Close from closing the queue while we wait
+		h.closeMu.RLock()
+		res := handler.EnqueueBlock(ctx, h.queue, entry, h.blockTimeout, h.closed)
+		h.closeMu.RUnlock()
+
+		switch res {
+		case handler.BlockEnqueued:
 			return nil
-		default:
-			// Queue full, use timer for timeout
-			if !h.blockTimer.Stop() {
-				select {
-				case <-h.blockTimer.C:
-				default:
-				}
-			}
-			h.blockTimer.Reset(h.blockTimeout)
-			select {
-			case h.queue <- entry:
-				if !h.blockTimer.Stop() {
-					select {
-					case <-h.blockTimer.C:
-					default:
-					}
-				}
-				return nil
-			case <-h.blockTimer.C:
-				// Timeout - fall back to synchronous write
-				h.stats.IncrementBlocked()
-				return h.write(entry, &h.parBufPool)
-			case <-h.closed:
-				// Handler is closing, write synchronously
-				if !h.blockTimer.Stop() {
-					select {
-					case <-h.blockTimer.C:
-					default:
-					}
-				}
-				return h.write(entry, &h.parBufPool)
+		case handler.BlockTimedOut, handler.BlockCanceled:
+			h.stats.IncrementBlocked()
+		}
+		if h.strictOrdering {
+			// Never bypass the queue: the entry is dropped instead
+			h.stats.IncrementDropped(entry.Level)
+			if res == handler.BlockCanceled {
+				return ctx.Err()
 			}
+			return nil
 		}
+		// Fall back to a synchronous write; write serializes on mu
+		return h.write(entry, &h.parBufPool)
 
 	case handler.DropOldest:
 		// Try non-blocking send
@@ -175,27 +182,22 @@ func (h *AsyncConsoleHandler) CanRecycleEntry() bool {
 	return false
 }
 
-// process handles async log processing
+// process handles async log processing. Write errors never stop the
+// goroutine; the supervisor retries, reports and falls back instead.
 func (h *AsyncConsoleHandler) process() {
 	defer h.wg.Done()
 
 	for {
 		select {
 		case entry := <-h.queue:
-			err := h.processWrite(entry, &h.parBufPool)
-			if err != nil {
-				return
-			}
+			h.supervisor.Write(entry)
 			core.PutEntry(entry)
 			// Batch drain: process additional queued entries without blocking
 		batchDrain:
 			for {
 				select {
 				case entry := <-h.queue:
-					err := h.processWrite(entry, &h.parBufPool)
-					if err != nil {
-						return
-					}
+					h.supervisor.Write(entry)
 					core.PutEntry(entry)
 				default:
 					break batchDrain
@@ -208,10 +210,7 @@ func (h *AsyncConsoleHandler) process() {
 			for {
 				select {
 				case entry := <-h.queue:
-					err := h.processWrite(entry, &h.parBufPool)
-					if err != nil {
-						return
-					}
+					h.supervisor.Write(entry)
 					core.PutEntry(entry)
 				case <-deadline:
 					// Timeout reached, stop draining
@@ -238,9 +237,10 @@ func (h *AsyncConsoleHandler) Close() error {
 	close(h.closed)
 	h.wg.Wait() // Wait without holding lock to avoid deadlock
 
-	h.mu.Lock()
+	// Wait for blocked producers to observe closed before closing the queue
+	h.closeMu.Lock()
 	close(h.queue)
-	h.mu.Unlock()
+	h.closeMu.Unlock()
 
 	return nil
 }
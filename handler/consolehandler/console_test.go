@@ -2,9 +2,12 @@ package consolehandler
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -36,6 +39,27 @@ func TestConsoleHandler_Sync(t *testing.T) {
 	}
 }
 
+func TestConsoleHandler_ColorAuto(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	f := formatter.NewTextFormatter(formatter.Config{Color: formatter.ColorAuto})
+
+	for _, force := range []string{"", "1"} {
+		t.Setenv("FORCE_COLOR", force)
+		var buf bytes.Buffer
+		h := NewConsoleHandler(ConsoleConfig{Writer: &buf, Async: false, Formatter: f})
+
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = "colored"
+		h.Handle(entry)
+		h.Close()
+
+		if colored := strings.Contains(buf.String(), "\x1b["); colored != (force != "") {
+			t.Errorf("FORCE_COLOR=%q: colored = %v, output %q", force, colored, buf.String())
+		}
+	}
+}
+
 func TestConsoleHandler_Async(t *testing.T) {
 	var buf bytes.Buffer
 	h := NewConsoleHandler(ConsoleConfig{
@@ -198,3 +222,83 @@ func TestConsoleHandler_ConcurrentSafe_Parallel(t *testing.T) {
 		t.Errorf("Expected %d processed, got %d", goroutines*msgs, snap.ProcessedTotal)
 	}
 }
+
+// failingWriter fails every Write while fail is set.
+type failingWriter struct {
+	mu   sync.Mutex
+	fail bool
+	buf  bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fail {
+		return 0, errors.New("broken pipe")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *failingWriter) setFail(fail bool) {
+	w.mu.Lock()
+	w.fail = fail
+	w.mu.Unlock()
+}
+
+func TestConsoleHandler_AsyncFallbackOnWriteError(t *testing.T) {
+	primary := &failingWriter{fail: true}
+	var fallbackBuf bytes.Buffer
+	fallback := NewConsoleHandler(ConsoleConfig{
+		Writer: &fallbackBuf,
+		Async:  false,
+	})
+	var errCount atomic.Int64
+	h := NewConsoleHandler(ConsoleConfig{
+		Writer:          primary,
+		Async:           true,
+		Fallback:        fallback,
+		MaxRetries:      -1,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+		ErrorHandler:    func(err error) { errCount.Add(1) },
+	})
+
+	handle := func(msg string) {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	waitProcessed := func(sp handler.StatsProvider, want uint64) {
+		for i := 0; i < 100; i++ {
+			s := sp.Stats()
+			if s.ProcessedTotal+s.FallbackTotal >= want {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	handle("while failing")
+	waitProcessed(h.(handler.StatsProvider), 1)
+
+	primary.setFail(false)
+	time.Sleep(5 * time.Millisecond) // Let the probe interval elapse
+	handle("after recovery")
+	waitProcessed(h.(handler.StatsProvider), 2)
+	h.Close()
+
+	if !strings.Contains(fallbackBuf.String(), "while failing") {
+		t.Errorf("Expected failed entry in fallback output, got: %s", fallbackBuf.String())
+	}
+	if !strings.Contains(primary.buf.String(), "after recovery") {
+		t.Errorf("Expected entry after recovery in primary output, got: %s", primary.buf.String())
+	}
+	stats := h.(handler.StatsProvider).Stats()
+	if stats.FallbackTotal != 1 || stats.RecoveredTotal != 1 || !stats.Healthy {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if errCount.Load() == 0 {
+		t.Error("Expected the ErrorHandler to be called")
+	}
+}
@@ -2,6 +2,11 @@ package consolehandler
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -100,6 +105,152 @@ func TestOverflowPolicy_DropOldest(t *testing.T) {
 	}
 }
 
+// gatedWriter blocks every Write until release is closed.
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestOverflowPolicy_BlockConcurrentProducers(t *testing.T) {
+	w := &gatedWriter{release: make(chan struct{})}
+	close(w.release)
+	h := NewConsoleHandler(ConsoleConfig{
+		Writer:       w,
+		Async:        true,
+		BufferSize:   1,
+		BlockTimeout: 5 * time.Second,
+		OverflowPolicy: map[core.Level]handler.OverflowPolicy{
+			core.ErrorLevel: handler.Block,
+		},
+	})
+
+	const producers = 16
+	const perProducer = 50
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				entry := core.GetEntry()
+				entry.Level = core.ErrorLevel
+				entry.Message = "error"
+				h.Handle(entry)
+			}
+		}()
+	}
+	wg.Wait()
+	h.Close()
+
+	if got := strings.Count(w.String(), "\n"); got != producers*perProducer {
+		t.Errorf("Expected %d lines, got %d", producers*perProducer, got)
+	}
+	if blocked := h.(handler.StatsProvider).Stats().BlockedTotal; blocked != 0 {
+		t.Errorf("Expected no timed-out producers, got %d", blocked)
+	}
+}
+
+func TestOverflowPolicy_BlockStrictOrdering(t *testing.T) {
+	w := &gatedWriter{release: make(chan struct{})}
+	h := NewConsoleHandler(ConsoleConfig{
+		Writer:         w,
+		Async:          true,
+		BufferSize:     2,
+		BlockTimeout:   5 * time.Millisecond,
+		StrictOrdering: true,
+		OverflowPolicy: map[core.Level]handler.OverflowPolicy{
+			core.ErrorLevel: handler.Block,
+		},
+	})
+
+	// The writer is stalled, so most of these time out
+	for i := 0; i < 20; i++ {
+		entry := core.GetEntry()
+		entry.Level = core.ErrorLevel
+		entry.Message = "error"
+		entry.Fields = append(entry.Fields, core.Field{Key: "seq", Type: core.IntType, Int64: int64(i)})
+		h.Handle(entry)
+	}
+	close(w.release)
+	h.Close()
+
+	stats := h.(handler.StatsProvider).Stats()
+	if stats.BlockedTotal == 0 {
+		t.Fatal("Expected timed-out entries with a stalled writer")
+	}
+	if stats.DroppedTotal[core.ErrorLevel] != stats.BlockedTotal {
+		t.Errorf("Expected every timed-out entry to be dropped, blocked=%d dropped=%d",
+			stats.BlockedTotal, stats.DroppedTotal[core.ErrorLevel])
+	}
+
+	last := -1
+	for _, line := range strings.Split(strings.TrimSpace(w.String()), "\n") {
+		idx := strings.Index(line, "seq=")
+		if idx < 0 {
+			t.Fatalf("Unexpected line %q", line)
+		}
+		seq, err := strconv.Atoi(line[idx+len("seq="):])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seq <= last {
+			t.Errorf("Entries out of order: %d after %d", seq, last)
+		}
+		last = seq
+	}
+}
+
+func TestOverflowPolicy_BlockContextDeadline(t *testing.T) {
+	w := &gatedWriter{release: make(chan struct{})}
+	h := NewConsoleHandler(ConsoleConfig{
+		Writer:         w,
+		Async:          true,
+		BufferSize:     1,
+		BlockTimeout:   10 * time.Second,
+		StrictOrdering: true,
+		OverflowPolicy: map[core.Level]handler.OverflowPolicy{
+			core.ErrorLevel: handler.Block,
+		},
+	})
+	defer func() {
+		close(w.release)
+		h.Close()
+	}()
+
+	ch := h.(handler.ContextHandler)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	for i := 0; i < 5 && err == nil; i++ {
+		entry := core.GetEntry()
+		entry.Level = core.ErrorLevel
+		entry.Message = "error"
+		err = ch.HandleContext(ctx, entry)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Context deadline not honoured, blocked for %v", elapsed)
+	}
+}
+
 func TestStats_Telemetry(t *testing.T) {
 	var buf bytes.Buffer
 	h := NewConsoleHandler(ConsoleConfig{
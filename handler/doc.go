@@ -11,16 +11,22 @@
 //     filehandler.NewFileHandler.
 //   - handler/multihandler – fan-out to multiple child handlers.
 //     Created via multihandler.NewMultiHandler.
+//   - handler/partitionhandler – one rotating file per field value,
+//     e.g. per tenant. Created via partitionhandler.NewPartitionHandler.
 //   - handler/sloghandler – adapter from Handler to log/slog.Handler.
 //     Created via sloghandler.NewSlogHandler.
+//   - handler/otlphandler – OpenTelemetry log export over OTLP/HTTP.
+//     Created via otlphandler.NewOTLPHandler.
 //
 // This package defines the shared interfaces and types used across all
 // sub-packages:
 //
-//   - Handler and FastHandler interfaces for log entry processing.
+//   - Handler, FastHandler, ContextHandler and LoggerFieldsHandler
+//     interfaces for log entry processing.
 //   - StatsProvider interface for runtime statistics monitoring.
 //   - OverflowPolicy (DropNewest, DropOldest, Block) for async queue
-//     overflow behavior.
+//     overflow behavior, and EnqueueBlock which implements the Block
+//     policy safely for many concurrent producers.
 //   - Stats and Snapshot types for tracking dropped, blocked, and
 //     processed log counts.
 package handler
@@ -0,0 +1,201 @@
+package filehandler
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// compressSuffix is appended to compressed backups
+	compressSuffix = ".gz"
+	// tmpSuffix marks a compressed backup that is still being written
+	tmpSuffix = ".tmp"
+)
+
+// startCompressor starts the background goroutine that gzips rotated
+// backups. It runs one pass immediately to pick up backups left behind
+// by a previous process.
+func (b *fileBase) startCompressor() {
+	if !b.compress {
+		return
+	}
+	b.compressSignal = make(chan struct{}, 1)
+	b.compressStop = make(chan struct{})
+	b.compressSignal <- struct{}{}
+
+	b.compressWG.Add(1)
+	go func() {
+		defer b.compressWG.Done()
+		for {
+			select {
+			case <-b.compressSignal:
+				b.compressBackups()
+			case <-b.compressStop:
+				// Finish backups rotated right before Close
+				select {
+				case <-b.compressSignal:
+					b.compressBackups()
+				default:
+				}
+				return
+			}
+		}
+	}()
+}
+
+// signalCompressor asks the compressor to look for new backups without blocking.
+func (b *fileBase) signalCompressor() {
+	if !b.compress {
+		return
+	}
+	select {
+	case b.compressSignal <- struct{}{}:
+	default: // A pass is already pending
+	}
+}
+
+// stopCompressor waits for the compressor to finish pending backups.
+func (b *fileBase) stopCompressor() {
+	if !b.compress {
+		return
+	}
+	close(b.compressStop)
+	b.compressWG.Wait()
+}
+
+// compressBackups gzips every uncompressed backup of the log file. Only the
+// compressor goroutine creates temp files, and in shared mode it holds the
+// rotation lock, so any temp file found here was left by a crash and is
+// removed.
+func (b *fileBase) compressBackups() {
+	if b.rotationLock != nil {
+		// Keep other processes from compressing the same backups
+		if err := b.rotationLock.lock(); err != nil {
+			b.stats.IncrementCompressErrors()
+			b.reportError(err)
+			return
+		}
+		defer b.rotationLock.unlock()
+	}
+	if b.pattern != nil {
+		b.compressPatternBackups()
+		return
+	}
+	dir := filepath.Dir(b.filename)
+
+	entries, err := b.fs.ReadDir(dir)
+	if err != nil {
+		b.stats.IncrementCompressErrors()
+		b.reportError(err)
+		return
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if tmp, ok := strings.CutSuffix(name, tmpSuffix); ok {
+			if info, ok := b.namer.parse(tmp); ok && info.compressed {
+				b.fs.Remove(filepath.Join(dir, name))
+			}
+			continue
+		}
+		if info, ok := b.namer.parse(name); !ok || info.compressed {
+			continue
+		}
+
+		b.compressBackup(filepath.Join(dir, name))
+	}
+}
+
+// compressBackup compresses one backup, recording the outcome in stats.
+func (b *fileBase) compressBackup(path string) {
+	// Backups left by a previous process also get OnRotate, so hold them too
+	b.markPending(path)
+	dst, err := compressFile(b.fs, path, b.compressLevel)
+	if err != nil && dst != "" {
+		// The complete .gz is in place and only removing path failed:
+		// retry that instead of compressing the backup again
+		if err = b.fs.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err != nil {
+		b.unmarkPending(path)
+		b.stats.IncrementCompressErrors()
+		b.reportError(err)
+		return
+	}
+	if dst == "" {
+		b.unmarkPending(path)
+		return
+	}
+	b.stats.IncrementCompressed()
+	b.notifyRotate(dst)
+}
+
+// compressFile gzips src into src.gz, removes src and returns the path of
+// the compressed file, or "" if src no longer exists. The output is written
+// to a temp file that is synced and renamed into place, so a crash never
+// leaves a truncated .gz behind. The modification time of src is kept so
+// retention still orders backups by rotation time. If only removing src
+// fails, the complete dst is returned along with the error.
+func compressFile(fsys FS, src string, level int) (dst string, err error) {
+	in, err := fsys.OpenFile(src, os.O_RDONLY, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil // Removed by retention in the meantime
+	}
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	dst = src + compressSuffix
+	tmp := dst + tmpSuffix
+	out, err := fsys.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			fsys.Remove(tmp)
+		}
+	}()
+
+	zw, err := gzip.NewWriterLevel(out, level)
+	if err != nil {
+		out.Close()
+		return "", err
+	}
+	zw.Name = filepath.Base(src)
+	zw.ModTime = info.ModTime()
+
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err = fsys.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		return "", err
+	}
+	if err = fsys.Rename(tmp, dst); err != nil {
+		return "", err
+	}
+	return dst, fsys.Remove(src)
+}
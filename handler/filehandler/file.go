@@ -3,6 +3,7 @@ package filehandler
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/philipp01105/nlog/core"
@@ -36,24 +38,68 @@ func (s *sizeTrackingWriter) reset(w io.Writer) {
 
 // fileBase contains shared fields and methods for file handlers.
 type fileBase struct {
-	filename        string
-	file            *os.File
-	bufWriter       *bufio.Writer
-	sizeWriter      *sizeTrackingWriter
-	formatter       formatter.Formatter
-	writerFormatter formatter.WriterFormatter
-	bufferFormatter formatter.BufferFormatter
-	mu              sync.Mutex
-	syncBuf         bytes.Buffer
-	maxSize         int64
-	maxAge          time.Duration
-	maxBackups      int
-	rotateInterval  time.Duration
-	currentSize     int64
-	lastRotateTime  time.Time
-	hasRotation     bool
-	stats           *handler.Stats
-	closed          chan struct{}
+	filename            string
+	file                File
+	fs                  FS
+	clock               core.Clock
+	bufWriter           *bufio.Writer
+	sizeWriter          *sizeTrackingWriter
+	formatter           formatter.Formatter
+	writerFormatter     formatter.WriterFormatter
+	bufferFormatter     formatter.BufferFormatter
+	mu                  sync.Mutex
+	syncBuf             bytes.Buffer
+	maxSize             int64
+	maxAge              time.Duration
+	maxBackups          int
+	rotateInterval      time.Duration
+	currentSize         int64
+	lastRotateTime      time.Time
+	hasRotation         bool
+	stats               *handler.Stats
+	closed              chan struct{}
+	onError             handler.ErrorHandler
+	flushInterval       time.Duration
+	flushOnLevel        bool
+	flushLevel          core.Level
+	fsyncPolicy         FsyncPolicy
+	fsyncInterval       time.Duration
+	fsyncEvery          int
+	sinceFsync          int
+	hasAfterWrite       bool           // true when afterWrite has work to do
+	bgWG                sync.WaitGroup // flusher and watcher goroutines
+	compress            bool
+	compressLevel       int
+	compressSignal      chan struct{}
+	compressStop        chan struct{}
+	compressWG          sync.WaitGroup
+	namer               *backupNamer
+	schedule            RotationSchedule
+	rotateAt            time.Duration
+	location            *time.Location
+	nextRotation        time.Time
+	symlink             string
+	maxBackupAge        time.Duration
+	maxTotalSize        int64
+	hasRetention        bool
+	retentionMu         sync.Mutex
+	onRotate            func(oldPath, newPath string)
+	onBackupRemoved     func(path string)
+	hookMu              sync.Mutex
+	hookQueue           []func()
+	hookSignal          chan struct{}
+	hookStopped         bool
+	hookWG              sync.WaitGroup
+	pendingRotate       map[string]struct{} // backups awaiting OnRotate, guarded by hookMu
+	header              bool
+	headerMessage       string
+	headerFields        []core.Field
+	reopenCheckInterval time.Duration
+	reopenOnSIGHUP      bool
+	rotationLock        *rotationLock // non-nil in shared mode
+	pattern             *datePattern  // non-nil when Filename has date directives
+	patternSeq          int
+	activePath          atomic.Pointer[string] // filename, for goroutines not holding mu
 }
 
 // write formats and writes an entry
@@ -73,6 +119,9 @@ func (b *fileBase) write(entry *core.Entry) error {
 		if err == nil {
 			b.currentSize += int64(n)
 			b.stats.IncrementProcessed()
+			if b.hasAfterWrite {
+				err = b.afterWrite(entry.Level)
+			}
 		}
 		b.mu.Unlock()
 		return err
@@ -92,6 +141,9 @@ func (b *fileBase) write(entry *core.Entry) error {
 			written := (b.sizeWriter.written - prevFlushed) + int64(b.bufWriter.Buffered()-prevBuffered)
 			b.currentSize += written
 			b.stats.IncrementProcessed()
+			if b.hasAfterWrite {
+				err = b.afterWrite(entry.Level)
+			}
 		}
 		b.mu.Unlock()
 		return err
@@ -112,6 +164,9 @@ func (b *fileBase) write(entry *core.Entry) error {
 	if err == nil {
 		b.currentSize += int64(n)
 		b.stats.IncrementProcessed()
+		if b.hasAfterWrite {
+			err = b.afterWrite(entry.Level)
+		}
 	}
 	b.mu.Unlock()
 
@@ -124,32 +179,49 @@ func (b *fileBase) rotateIfNeeded() error {
 		return nil
 	}
 
-	needRotate := false
+	if b.rotationLock != nil {
+		if err := b.syncShared(); err != nil {
+			return err
+		}
+	}
+
+	if !b.needRotate() {
+		return nil
+	}
+
+	if b.rotationLock != nil {
+		return b.rotateShared()
+	}
+	return b.rotate()
+}
 
+// needRotate reports whether any rotation trigger has fired
+func (b *fileBase) needRotate() bool {
 	// Check size-based rotation
 	if b.maxSize > 0 && b.currentSize >= b.maxSize {
-		needRotate = true
+		return true
 	}
 
 	// Check time-based rotation (by age)
-	if b.maxAge > 0 && time.Since(b.lastRotateTime) >= b.maxAge {
-		needRotate = true
+	if b.maxAge > 0 && b.clock.Now().Sub(b.lastRotateTime) >= b.maxAge {
+		return true
 	}
 
 	// Check interval-based rotation
-	if b.rotateInterval > 0 && time.Since(b.lastRotateTime) >= b.rotateInterval {
-		needRotate = true
+	if b.rotateInterval > 0 && b.clock.Now().Sub(b.lastRotateTime) >= b.rotateInterval {
+		return true
 	}
 
-	if !needRotate {
-		return nil
-	}
-
-	return b.rotate()
+	// Check wall-clock scheduled rotation or the end of the pattern's period
+	return (b.schedule != ScheduleNone || b.pattern != nil) && !b.clock.Now().Before(b.nextRotation)
 }
 
 // rotate performs the actual file rotation
 func (b *fileBase) rotate() error {
+	if b.pattern != nil {
+		return b.rotatePattern()
+	}
+
 	// Flush buffered writer, sync and close current file
 	if err := b.bufWriter.Flush(); err != nil {
 		return err
@@ -161,13 +233,14 @@ func (b *fileBase) rotate() error {
 		return err
 	}
 
-	// Rename current file with timestamp
-	timestamp := time.Now().Format("2006-01-02T15-04-05")
-	rotatedName := fmt.Sprintf("%s.%s", b.filename, timestamp)
+	// Rename current file following the backup name template
+	now := b.clock.Now()
+	dir := filepath.Dir(b.filename)
+	rotatedName := filepath.Join(dir, b.namer.format(now, b.namer.nextSeq(b.fs, dir)))
 
-	if err := os.Rename(b.filename, rotatedName); err != nil {
+	if err := b.fs.Rename(b.filename, rotatedName); err != nil {
 		// If rename fails, try to reopen the original file
-		file, openErr := os.OpenFile(b.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		file, openErr := b.fs.OpenFile(b.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		if openErr != nil {
 			return fmt.Errorf("rotation failed: %v, reopen failed: %v", err, openErr)
 		}
@@ -175,13 +248,22 @@ func (b *fileBase) rotate() error {
 		return err
 	}
 
-	// Clean up old backups if needed
-	if b.maxBackups > 0 {
-		b.cleanupOldBackups()
+	// Clean up old backups if needed, keeping this one until OnRotate has seen it
+	b.markPending(rotatedName)
+	if b.hasRetention {
+		if _, err := b.ApplyRetention(); err != nil {
+			b.reportError(err)
+		}
+	}
+	if b.compress {
+		// OnRotate fires once the compressed backup is in place
+		b.signalCompressor()
+	} else {
+		b.notifyRotate(rotatedName)
 	}
 
 	// Open new file
-	file, err := os.OpenFile(b.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	file, err := b.fs.OpenFile(b.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return err
 	}
@@ -190,51 +272,75 @@ func (b *fileBase) rotate() error {
 	b.sizeWriter.reset(file)
 	b.bufWriter.Reset(b.sizeWriter)
 	b.currentSize = 0
-	b.lastRotateTime = time.Now()
+	b.lastRotateTime = now
+	if b.schedule != ScheduleNone {
+		b.nextRotation = b.schedule.next(now, b.rotateAt, b.location)
+	}
+	if err := b.updateSymlink(); err != nil {
+		b.reportError(err)
+	}
+	if b.header {
+		if err := b.writeHeaderLocked(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-// cleanupOldBackups removes old backup files based on MaxBackups
-func (b *fileBase) cleanupOldBackups() {
-	dir := filepath.Dir(b.filename)
-	base := filepath.Base(b.filename)
+// backupGroup is a rotated backup with all files that belong to it: the
+// plain file and/or its compressed .gz form.
+type backupGroup struct {
+	info  backupInfo
+	paths []string
+}
 
-	// Find all backup files
-	pattern := filepath.Join(dir, base+".*")
-	matches, err := filepath.Glob(pattern)
+// listBackups returns the backups of the log file, oldest first. Only
+// names produced by the backup name template, or by the filename pattern
+// other than the active file, are considered, so other files sharing the
+// prefix are never touched.
+func (b *fileBase) listBackups() ([]backupGroup, error) {
+	if b.pattern != nil {
+		return b.listPatternBackups()
+	}
+
+	dir := filepath.Dir(b.filename)
+	entries, err := b.fs.ReadDir(dir)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	// Filter to only timestamp-based backups
-	var backups []string
-	for _, match := range matches {
-		if strings.HasPrefix(filepath.Base(match), base+".") {
-			backups = append(backups, match)
+	index := make(map[string]int)
+	var backups []backupGroup
+	for _, e := range entries {
+		name := e.Name()
+		info, ok := b.namer.parse(name)
+		if !ok {
+			continue
+		}
+		key := strings.TrimSuffix(name, compressSuffix)
+		i, seen := index[key]
+		if !seen {
+			i = len(backups)
+			index[key] = i
+			backups = append(backups, backupGroup{info: info})
 		}
+		backups[i].paths = append(backups[i].paths, filepath.Join(dir, name))
 	}
 
-	// Sort by modification time (oldest first)
-	sort.Slice(backups, func(i, j int) bool {
-		infoI, errI := os.Stat(backups[i])
-		infoJ, errJ := os.Stat(backups[j])
-		if errI != nil || errJ != nil {
-			return false
-		}
-		return infoI.ModTime().Before(infoJ.ModTime())
-	})
+	sortBackups(backups)
+	return backups, nil
+}
 
-	// Remove oldest files if we exceed MaxBackups
-	if len(backups) > b.maxBackups {
-		toRemove := backups[:len(backups)-b.maxBackups]
-		for _, file := range toRemove {
-			err := os.Remove(file)
-			if err != nil {
-				return
-			}
+// sortBackups orders backups by rotation time, then sequence number.
+func sortBackups(backups []backupGroup) {
+	sort.SliceStable(backups, func(i, j int) bool {
+		a, c := backups[i].info, backups[j].info
+		if !a.time.Equal(c.time) {
+			return a.time.Before(c.time)
 		}
-	}
+		return a.seq < c.seq
+	})
 }
 
 // Stats returns a snapshot of the current statistics
@@ -243,7 +349,14 @@ func (b *fileBase) Stats() handler.Snapshot {
 }
 
 // closeFile flushes, syncs and closes the underlying file.
+// The handler must have closed b.closed so the flusher and watcher
+// goroutines exit.
 func (b *fileBase) closeFile() error {
+	b.bgWG.Wait()
+	defer b.rotationLock.close()
+	defer b.stopHooks() // After the compressor, which may still queue OnRotate
+	defer b.stopCompressor()
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -266,7 +379,12 @@ func (b *fileBase) closeFile() error {
 
 // FileConfig holds configuration for file handler
 type FileConfig struct {
-	// Filename is the path to the log file
+	// Filename is the path to the log file. It may contain strftime-style
+	// date directives (%Y %y %m %d %j %H %M %S, %% for a literal %), e.g.
+	// logs/%Y-%m/app-%Y-%m-%d.log; the handler then writes directly into
+	// the file for the current period and switches files when the period
+	// ends. MaxSize adds a sequence number (app-2026-10-16.1.log), and
+	// retention and compression apply to the files of past periods.
 	Filename string
 	// Formatter to use (default: TextFormatter)
 	Formatter formatter.Formatter
@@ -280,6 +398,11 @@ type FileConfig struct {
 	MaxAge time.Duration
 	// MaxBackups is the maximum number of old log files to retain (0 = keep all)
 	MaxBackups int
+	// MaxBackupAge removes backups rotated longer ago than this (0 = no age limit)
+	MaxBackupAge time.Duration
+	// MaxTotalSize is the maximum total size in bytes of all backups; the
+	// oldest are removed first (0 = no size limit)
+	MaxTotalSize int64
 	// RotateInterval is the interval for time-based rotation (0 = no interval rotation)
 	RotateInterval time.Duration
 	// OverflowPolicy defines per-level overflow behavior (default: uses DefaultLevelPolicy)
@@ -288,6 +411,107 @@ type FileConfig struct {
 	BlockTimeout time.Duration
 	// DrainTimeout is the timeout for draining queue on Close (default: 5s)
 	DrainTimeout time.Duration
+	// StrictOrdering makes the Block policy drop entries that time out
+	// (or whose context is done) instead of writing them synchronously
+	// from the caller, so entries never bypass the queue and are always
+	// written in enqueue order. Dropped entries are counted in both
+	// BlockedTotal and DroppedTotal.
+	StrictOrdering bool
+	// ErrorHandler is called from the background goroutine for every
+	// write error of an async handler (default: none)
+	ErrorHandler handler.ErrorHandler
+	// Fallback receives entries while an async handler's output keeps
+	// failing, e.g. a console handler on os.Stderr (default: none, entries
+	// are dropped)
+	Fallback handler.Handler
+	// MaxRetries is the number of retries for a transient write error
+	// before falling back (default: 3, negative disables retries)
+	MaxRetries int
+	// RetryBackoff is the initial delay between retries, doubled per
+	// attempt (default: 10ms)
+	RetryBackoff time.Duration
+	// MaxRetryBackoff caps the retry delay and the interval at which a
+	// failing output is probed for recovery (default: 5s)
+	MaxRetryBackoff time.Duration
+	// FlushInterval flushes the write buffer periodically so readers such
+	// as tail -f see quiet output promptly (0 = flush only when the buffer
+	// is full, on rotation and on Close)
+	FlushInterval time.Duration
+	// FlushOnLevel enables flushing immediately after any entry at or
+	// above FlushLevel
+	FlushOnLevel bool
+	// FlushLevel is the minimum level flushed immediately when FlushOnLevel is set
+	FlushLevel core.Level
+	// FsyncPolicy defines when the file is fsynced (default: FsyncNever)
+	FsyncPolicy FsyncPolicy
+	// FsyncInterval is the fsync period for FsyncInterval (default: 1s)
+	FsyncInterval time.Duration
+	// FsyncEvery is the number of entries between fsyncs for FsyncEveryN (default: 100)
+	FsyncEvery int
+	// Compress gzips rotated backups in a background goroutine (default: false)
+	Compress bool
+	// CompressLevel is the gzip compression level (default: gzip.DefaultCompression)
+	CompressLevel int
+	// RotateSchedule rotates at wall-clock boundaries, e.g. daily at
+	// midnight, independent of when the process started (default: ScheduleNone)
+	RotateSchedule RotationSchedule
+	// RotateAt is the offset of scheduled rotation from the boundary: the
+	// time of day for ScheduleDaily, minutes past the hour for ScheduleHourly
+	RotateAt time.Duration
+	// Location is the time zone for scheduled rotation and backup
+	// timestamps (default: time.Local)
+	Location *time.Location
+	// BackupTemplate names rotated backups using the placeholders
+	// {filename}, {name}, {ext}, {time} and {seq}; it must contain {time}
+	// or {seq} (default: DefaultBackupTemplate). Use {seq} when rotating
+	// more often than BackupTimeFormat resolves, otherwise a backup from
+	// the same period is overwritten.
+	BackupTemplate string
+	// BackupTimeFormat is the time layout for {time} (default: DefaultBackupTimeFormat)
+	BackupTimeFormat string
+	// Symlink, if set, is kept pointing at the active log file
+	Symlink string
+	// OnRotate is called with the path of each finished backup and the
+	// path of the active log file. With Compress it is called once the
+	// .gz backup is in place, including for backups left uncompressed by
+	// a previous process. Callbacks run in order on a separate goroutine,
+	// so they may block, e.g. to upload the backup. Retention keeps a
+	// backup until its OnRotate has returned.
+	OnRotate func(oldPath, newPath string)
+	// OnBackupRemoved is called with the path of each backup file removed
+	// by retention. It runs on the same goroutine as OnRotate.
+	OnBackupRemoved func(path string)
+	// Header writes a header entry at the start of every new log file with
+	// HeaderFields followed by the app version, hostname and pid
+	Header bool
+	// HeaderMessage is the message of the header entry (default: DefaultHeaderMessage)
+	HeaderMessage string
+	// HeaderFields are additional fields for the header entry
+	HeaderFields []core.Field
+	// AppVersion is the version in the header entry (default: the main
+	// module version from the build info, if any)
+	AppVersion string
+	// ReopenCheckInterval periodically checks whether the log file was
+	// renamed, removed or truncated by an external tool such as logrotate
+	// and reopens it if so (0 = no checks)
+	ReopenCheckInterval time.Duration
+	// ReopenOnSIGHUP reopens the log file when the process receives SIGHUP,
+	// as sent by logrotate's postrotate script
+	ReopenOnSIGHUP bool
+	// Shared coordinates several processes appending to the same Filename.
+	// Rotation and compression take an advisory lock on Filename+".lock",
+	// so exactly one process rotates and the others reopen the new file.
+	// Rotation uses the real file size, and every entry is flushed on its
+	// own so lines from different processes never interleave. Unix only.
+	Shared bool
+	// FS is the filesystem the log file and its backups live on, e.g. a
+	// MemFS in tests (default: OSFS). Shared requires OSFS.
+	FS FS
+	// Clock supplies the time for rotation, retention, backup names, the
+	// header entry and recovery probes, e.g. a manual clock in tests
+	// (default: core.SystemClock). Flush and fsync intervals and retry
+	// delays use real time.
+	Clock core.Clock
 }
 
 // applyFileDefaults fills in zero-value fields with defaults.
@@ -307,13 +531,67 @@ func applyFileDefaults(cfg *FileConfig) {
 	if cfg.DrainTimeout == 0 {
 		cfg.DrainTimeout = 5 * time.Second
 	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 10 * time.Millisecond
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = 5 * time.Second
+	}
+	if cfg.MaxRetryBackoff < cfg.RetryBackoff {
+		cfg.MaxRetryBackoff = cfg.RetryBackoff
+	}
+	if cfg.FsyncInterval <= 0 {
+		cfg.FsyncInterval = time.Second
+	}
+	if cfg.FsyncEvery <= 0 {
+		cfg.FsyncEvery = 100
+	}
+	if cfg.CompressLevel == 0 {
+		cfg.CompressLevel = gzip.DefaultCompression
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	if cfg.BackupTemplate == "" {
+		cfg.BackupTemplate = DefaultBackupTemplate
+	}
+	if cfg.BackupTimeFormat == "" {
+		cfg.BackupTimeFormat = DefaultBackupTimeFormat
+	}
+	if cfg.HeaderMessage == "" {
+		cfg.HeaderMessage = DefaultHeaderMessage
+	}
+	if cfg.FS == nil {
+		cfg.FS = OSFS{}
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = core.SystemClock{}
+	}
+}
+
+// fileSetup holds what NewFileHandler prepared for a handler constructor.
+type fileSetup struct {
+	file    File
+	size    int64
+	namer   *backupNamer
+	pattern *datePattern  // non-nil when Filename has date directives
+	seq     int           // sequence number of the active pattern file
+	lock    *rotationLock // non-nil in shared mode
 }
 
 // initFileBase initializes a fileBase in place with the given config and opened file.
-func initFileBase(b *fileBase, cfg FileConfig, file *os.File, fileSize int64) {
+func initFileBase(b *fileBase, cfg FileConfig, setup fileSetup) {
+	file := setup.file
 	sw := &sizeTrackingWriter{w: file}
 	b.filename = cfg.Filename
 	b.file = file
+	b.fs = cfg.FS
+	b.clock = cfg.Clock
 	b.sizeWriter = sw
 	b.bufWriter = bufio.NewWriterSize(sw, 4096)
 	b.formatter = cfg.Formatter
@@ -321,11 +599,49 @@ func initFileBase(b *fileBase, cfg FileConfig, file *os.File, fileSize int64) {
 	b.maxAge = cfg.MaxAge
 	b.maxBackups = cfg.MaxBackups
 	b.rotateInterval = cfg.RotateInterval
-	b.currentSize = fileSize
-	b.lastRotateTime = time.Now()
-	b.hasRotation = cfg.MaxSize > 0 || cfg.MaxAge > 0 || cfg.RotateInterval > 0
+	b.currentSize = setup.size
+	b.lastRotateTime = cfg.Clock.Now()
+	b.hasRotation = cfg.MaxSize > 0 || cfg.MaxAge > 0 || cfg.RotateInterval > 0 || cfg.RotateSchedule != ScheduleNone || setup.pattern != nil
 	b.closed = make(chan struct{})
 	b.stats = handler.NewStats()
+	b.onError = cfg.ErrorHandler
+	b.flushInterval = cfg.FlushInterval
+	b.flushOnLevel = cfg.FlushOnLevel
+	b.flushLevel = cfg.FlushLevel
+	b.fsyncPolicy = cfg.FsyncPolicy
+	b.fsyncInterval = cfg.FsyncInterval
+	b.fsyncEvery = cfg.FsyncEvery
+	b.hasAfterWrite = cfg.FlushOnLevel || cfg.FsyncPolicy == FsyncEveryN || setup.lock != nil
+	b.compress = cfg.Compress
+	b.compressLevel = cfg.CompressLevel
+	b.namer = setup.namer
+	b.pattern = setup.pattern
+	b.patternSeq = setup.seq
+	active := cfg.Filename
+	b.activePath.Store(&active)
+	b.schedule = cfg.RotateSchedule
+	b.rotateAt = cfg.RotateAt
+	b.location = cfg.Location
+	if b.schedule != ScheduleNone {
+		b.nextRotation = b.schedule.next(b.lastRotateTime, b.rotateAt, b.location)
+	}
+	if b.pattern != nil {
+		b.nextRotation = b.pattern.next(b.lastRotateTime)
+	}
+	b.symlink = cfg.Symlink
+	b.maxBackupAge = cfg.MaxBackupAge
+	b.maxTotalSize = cfg.MaxTotalSize
+	b.hasRetention = cfg.MaxBackups > 0 || cfg.MaxBackupAge > 0 || cfg.MaxTotalSize > 0
+	b.onRotate = cfg.OnRotate
+	b.onBackupRemoved = cfg.OnBackupRemoved
+	b.header = cfg.Header
+	b.reopenCheckInterval = cfg.ReopenCheckInterval
+	b.reopenOnSIGHUP = cfg.ReopenOnSIGHUP
+	b.rotationLock = setup.lock
+	if b.header {
+		b.headerMessage = cfg.HeaderMessage
+		b.headerFields = headerFields(cfg)
+	}
 
 	// Cache WriterFormatter for zero-alloc path
 	b.writerFormatter, _ = cfg.Formatter.(formatter.WriterFormatter)
@@ -348,30 +664,65 @@ func NewFileHandler(cfg FileConfig) (handler.Handler, error) {
 	}
 	applyFileDefaults(&cfg)
 
+	setup := fileSetup{}
+	if isDatePattern(cfg.Filename) {
+		if err := checkPatternConfig(cfg); err != nil {
+			return nil, err
+		}
+		pattern, err := parseDatePattern(cfg.Filename, cfg.Location)
+		if err != nil {
+			return nil, err
+		}
+		setup.pattern = pattern
+		cfg.Filename, setup.seq = pattern.active(cfg.FS, cfg.Clock.Now())
+	}
+
+	namer, err := newBackupNamer(cfg.Filename, cfg.BackupTemplate, cfg.BackupTimeFormat, cfg.Location)
+	if err != nil {
+		return nil, err
+	}
+	setup.namer = namer
+
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(cfg.Filename)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := cfg.FS.MkdirAll(dir, 0755); err != nil {
 		return nil, err
 	}
 
+	if cfg.Shared {
+		if !sharedSupported {
+			return nil, errSharedUnsupported
+		}
+		if _, ok := cfg.FS.(OSFS); !ok {
+			return nil, errSharedFS
+		}
+		if setup.lock, err = openRotationLock(cfg.Filename + lockSuffix); err != nil {
+			return nil, err
+		}
+	}
+
 	// Open file
-	file, err := os.OpenFile(cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	file, err := cfg.FS.OpenFile(cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
+		setup.lock.close()
 		return nil, err
 	}
 
 	// Get file size
 	info, err := file.Stat()
 	if err != nil {
+		setup.lock.close()
 		closeErr := file.Close()
 		if closeErr != nil {
 			return nil, closeErr
 		}
 		return nil, err
 	}
+	setup.file = file
+	setup.size = info.Size()
 
 	if cfg.Async {
-		return newAsyncFileHandler(cfg, file, info.Size()), nil
+		return newAsyncFileHandler(cfg, setup), nil
 	}
-	return newSyncFileHandler(cfg, file, info.Size()), nil
+	return newSyncFileHandler(cfg, setup), nil
 }
@@ -1,7 +1,7 @@
 package filehandler
 
 import (
-	"os"
+	"context"
 	"sync"
 	"time"
 
@@ -19,18 +19,47 @@ type AsyncFileHandler struct {
 	overflowPolicy map[core.Level]handler.OverflowPolicy
 	blockTimeout   time.Duration
 	drainTimeout   time.Duration
-	blockTimer     *time.Timer
+	strictOrdering bool
+	closeMu        sync.RWMutex // held shared by blocked producers, exclusively by Close
+	supervisor     *handler.Supervisor
 }
 
 // newAsyncFileHandler creates a new asynchronous file handler.
-func newAsyncFileHandler(cfg FileConfig, file *os.File, fileSize int64) *AsyncFileHandler {
+func newAsyncFileHandler(cfg FileConfig, setup fileSetup) *AsyncFileHandler {
 	h := &AsyncFileHandler{
 		overflowPolicy: cfg.OverflowPolicy,
 		blockTimeout:   cfg.BlockTimeout,
 		drainTimeout:   cfg.DrainTimeout,
-		blockTimer:     handler.NewStoppedTimer(),
+		strictOrdering: cfg.StrictOrdering,
 	}
-	initFileBase(&h.fileBase, cfg, file, fileSize)
+	initFileBase(&h.fileBase, cfg, setup)
+	if err := h.updateSymlink(); err != nil {
+		h.reportError(err)
+	}
+	if h.header && setup.size == 0 {
+		h.mu.Lock()
+		if err := h.writeHeaderLocked(); err != nil {
+			h.reportError(err)
+		}
+		h.mu.Unlock()
+	}
+	h.supervisor = handler.NewSupervisor(handler.SupervisorConfig{
+		Write:           h.write,
+		Recover:         h.Reopen,
+		Fallback:        cfg.Fallback,
+		ErrorHandler:    cfg.ErrorHandler,
+		MaxRetries:      cfg.MaxRetries,
+		RetryBackoff:    cfg.RetryBackoff,
+		MaxRetryBackoff: cfg.MaxRetryBackoff,
+		Stats:           h.stats,
+		Closed:          h.closed,
+		Clock:           cfg.Clock,
+	})
+
+	h.startFlusher()
+	h.startWatcher()
+	h.startHooks()
+	h.startCompressor()
 
 	h.queue = make(chan *core.Entry, cfg.BufferSize)
 	h.wg.Add(1)
@@ -58,6 +87,13 @@ func (h *AsyncFileHandler) HandleLog(t time.Time, level core.Level, msg string,
 
 // Handle sends a log entry to the async queue with overflow policy handling.
 func (h *AsyncFileHandler) Handle(entry *core.Entry) error {
+	return h.HandleContext(context.Background(), entry)
+}
+
+// HandleContext sends a log entry to the async queue with overflow policy
+// handling. Under the Block policy the wait is additionally bounded by
+// the deadline and cancellation of ctx.
+func (h *AsyncFileHandler) HandleContext(ctx context.Context, entry *core.Entry) error {
 	// Get overflow policy for this level
 	policy, ok := h.overflowPolicy[entry.Level]
 	if !ok {
@@ -66,43 +102,27 @@ func (h *AsyncFileHandler) Handle(entry *core.Entry) error {
 
 	switch policy {
 	case handler.Block:
-		// Try to send with timeout using reusable timer
-		select {
-		case h.queue <- entry:
+		// Shared lock keeps Close from closing the queue while we wait
+		h.closeMu.RLock()
+		res := handler.EnqueueBlock(ctx, h.queue, entry, h.blockTimeout, h.closed)
+		h.closeMu.RUnlock()
+
+		switch res {
+		case handler.BlockEnqueued:
 			return nil
-		default:
-			// Queue full, use timer for timeout
-			if !h.blockTimer.Stop() {
-				select {
-				case <-h.blockTimer.C:
-				default:
-				}
-			}
-			h.blockTimer.Reset(h.blockTimeout)
-			select {
-			case h.queue <- entry:
-				if !h.blockTimer.Stop() {
-					select {
-					case <-h.blockTimer.C:
-					default:
-					}
-				}
-				return nil
-			case <-h.blockTimer.C:
-				// Timeout - fall back to synchronous write
-				h.stats.IncrementBlocked()
-				return h.write(entry)
-			case <-h.closed:
-				// Handler is closing, write synchronously
-				if !h.blockTimer.Stop() {
-					select {
-					case <-h.blockTimer.C:
-					default:
-					}
-				}
-				return h.write(entry)
+		case handler.BlockTimedOut, handler.BlockCanceled:
+			h.stats.IncrementBlocked()
+		}
+		if h.strictOrdering {
+			// Never bypass the queue: the entry is dropped instead
+			h.stats.IncrementDropped(entry.Level)
+			if res == handler.BlockCanceled {
+				return ctx.Err()
 			}
+			return nil
 		}
+		// Fall back to a synchronous write; write serializes on mu
+		return h.write(entry)
 
 	case handler.DropOldest:
 		// Try non-blocking send
@@ -148,32 +168,30 @@ func (h *AsyncFileHandler) CanRecycleEntry() bool {
 	return false
 }
 
-// process handles async log processing
+// process handles async log processing. Write errors never stop the
+// goroutine; the supervisor retries, reports and falls back instead.
 func (h *AsyncFileHandler) process() {
 	defer h.wg.Done()
 
 	for {
 		select {
 		case entry := <-h.queue:
-			err := h.write(entry)
-			if err != nil {
-				return
-			}
+			h.supervisor.Write(entry)
 			core.PutEntry(entry)
 			// Batch drain: process additional queued entries without blocking
 		batchDrain:
 			for {
 				select {
 				case entry := <-h.queue:
-					err := h.write(entry)
-					if err != nil {
-						return
-					}
+					h.supervisor.Write(entry)
 					core.PutEntry(entry)
 				default:
 					break batchDrain
 				}
 			}
+			if err := h.endBatch(); err != nil {
+				h.reportError(err)
+			}
 		case <-h.closed:
 			// Drain remaining entries with timeout
 			deadline := time.After(h.drainTimeout)
@@ -181,10 +199,7 @@ func (h *AsyncFileHandler) process() {
 			for {
 				select {
 				case entry := <-h.queue:
-					err := h.write(entry)
-					if err != nil {
-						return
-					}
+					h.supervisor.Write(entry)
 					core.PutEntry(entry)
 				case <-deadline:
 					// Timeout reached, stop draining
@@ -211,9 +226,10 @@ func (h *AsyncFileHandler) Close() error {
 	close(h.closed)
 	h.wg.Wait() // Wait without holding lock to avoid deadlock
 
-	h.mu.Lock()
+	// Wait for blocked producers to observe closed before closing the queue
+	h.closeMu.Lock()
 	close(h.queue)
-	h.mu.Unlock()
+	h.closeMu.Unlock()
 
 	return h.closeFile()
 }
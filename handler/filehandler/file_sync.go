@@ -1,7 +1,6 @@
 package filehandler
 
 import (
-	"os"
 	"time"
 
 	"github.com/philipp01105/nlog/core"
@@ -16,9 +15,28 @@ type SyncFileHandler struct {
 }
 
 // newSyncFileHandler creates a new synchronous file handler.
-func newSyncFileHandler(cfg FileConfig, file *os.File, fileSize int64) *SyncFileHandler {
+func newSyncFileHandler(cfg FileConfig, setup fileSetup) *SyncFileHandler {
 	h := &SyncFileHandler{}
-	initFileBase(&h.fileBase, cfg, file, fileSize)
+	if cfg.FsyncPolicy == FsyncBatch {
+		// Every call is its own batch
+		cfg.FsyncPolicy = FsyncEveryN
+		cfg.FsyncEvery = 1
+	}
+	initFileBase(&h.fileBase, cfg, setup)
+	if err := h.updateSymlink(); err != nil {
+		h.reportError(err)
+	}
+	if h.header && setup.size == 0 {
+		h.mu.Lock()
+		if err := h.writeHeaderLocked(); err != nil {
+			h.reportError(err)
+		}
+		h.mu.Unlock()
+	}
+	h.startFlusher()
+	h.startWatcher()
+	h.startHooks()
+	h.startCompressor()
 	// Pre-allocate syncEntry fields if bufferFormatter is available
 	if h.bufferFormatter != nil {
 		h.syncEntry.Fields = make([]core.Field, 0, 16)
@@ -53,6 +71,9 @@ func (h *SyncFileHandler) HandleLog(t time.Time, level core.Level, msg string, l
 		if err == nil {
 			h.currentSize += int64(n)
 			h.stats.IncrementProcessed()
+			if h.hasAfterWrite {
+				err = h.afterWrite(level)
+			}
 		}
 		h.mu.Unlock()
 		return err
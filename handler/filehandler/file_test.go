@@ -1,10 +1,18 @@
 package filehandler
 
 import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/handler"
+	"github.com/philipp01105/nlog/nlogtest"
 )
 
 func TestFileHandler_MaxBackups(t *testing.T) {
@@ -70,6 +78,57 @@ func TestFileHandler_RotateInterval(t *testing.T) {
 	// (In practice you'd verify the rotated file exists)
 }
 
+func TestFileHandler_ManualClock(t *testing.T) {
+	clock := nlogtest.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsys := NewMemFS()
+	fsys.Clock = clock
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:         "/logs/app.log",
+		Async:            false,
+		RotateInterval:   time.Hour,
+		MaxBackupAge:     30 * time.Minute,
+		BackupTemplate:   "{name}-{time}{ext}",
+		BackupTimeFormat: "2006-01-02T15",
+		Location:         time.UTC,
+		FS:               fsys,
+		Clock:            clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "first"
+	h.Handle(entry)
+
+	entry = core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "still first"
+	h.Handle(entry) // No time passed, no rotation
+	for _, msg := range []string{"second", "third"} {
+		clock.Advance(time.Hour)
+		entry = core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The backup rotated at 01:00 is older than MaxBackupAge at 02:00
+	backups, _ := fsys.Glob("/logs/app-*.log")
+	if want := "/logs/app-2024-01-01T02.log"; strings.Join(backups, ",") != want {
+		t.Errorf("Expected backups %s, got %v", want, backups)
+	}
+	data, _ := fsys.ReadFile("/logs/app-2024-01-01T02.log")
+	if !strings.Contains(string(data), "second") || strings.Contains(string(data), "third") {
+		t.Errorf("Unexpected backup content: %q", data)
+	}
+}
+
 func TestFileHandler_SyncOnClose(t *testing.T) {
 	dir := t.TempDir()
 	filename := dir + "/test.log"
@@ -93,3 +152,331 @@ func TestFileHandler_SyncOnClose(t *testing.T) {
 		t.Errorf("Close failed: %v", err)
 	}
 }
+
+func TestFileHandler_BlockConcurrentProducers(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/test.log"
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:       filename,
+		Async:          true,
+		BufferSize:     1,
+		BlockTimeout:   5 * time.Second,
+		StrictOrdering: true,
+		OverflowPolicy: map[core.Level]handler.OverflowPolicy{
+			core.ErrorLevel: handler.Block,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const producers = 16
+	const perProducer = 50
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				entry := core.GetEntry()
+				entry.Level = core.ErrorLevel
+				entry.Message = "error"
+				h.Handle(entry)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "\n"); got != producers*perProducer {
+		t.Errorf("Expected %d lines, got %d", producers*perProducer, got)
+	}
+}
+
+func TestFileHandler_AsyncRecoversFromWriteError(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/test.log"
+
+	var mu sync.Mutex
+	var reported []error
+	h, err := NewFileHandler(FileConfig{
+		Filename:     filename,
+		Async:        true,
+		MaxSize:      1, // Rotation check flushes the closed file on every write
+		RetryBackoff: time.Millisecond,
+		ErrorHandler: func(err error) {
+			mu.Lock()
+			reported = append(reported, err)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pull the file out from under the handler
+	fb := &h.(*AsyncFileHandler).fileBase
+	fb.mu.Lock()
+	fb.file.Close()
+	fb.mu.Unlock()
+
+	for i := 0; i < 5; i++ {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = "after failure"
+		h.Handle(entry)
+	}
+
+	afh := h.(*AsyncFileHandler)
+	for i := 0; i < 100 && afh.Stats().ProcessedTotal < 5; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) == 0 {
+		t.Error("Expected the ErrorHandler to be called")
+	}
+	stats := afh.Stats()
+	if !stats.Healthy {
+		t.Error("Expected handler to be healthy after reopening the file")
+	}
+	if stats.ProcessedTotal != 5 {
+		t.Errorf("Expected 5 processed entries, got %d", stats.ProcessedTotal)
+	}
+}
+
+func TestFileHandler_FlushInterval(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/test.log"
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:      filename,
+		Async:         false,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "quiet service"
+	h.Handle(entry)
+
+	sfh := h.(*SyncFileHandler)
+	for i := 0; i < 50 && sfh.Stats().FlushTotal == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sfh.Stats().FlushTotal == 0 {
+		t.Error("Expected flush to be recorded in stats")
+	}
+	data, _ := os.ReadFile(filename)
+	if !strings.Contains(string(data), "quiet service") {
+		t.Errorf("Expected entry to be flushed without Close, got %q", data)
+	}
+}
+
+func TestFileHandler_FlushOnLevel(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/test.log"
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:     filename,
+		Async:        false,
+		FlushOnLevel: true,
+		FlushLevel:   core.ErrorLevel,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "buffered"
+	h.Handle(entry)
+
+	data, _ := os.ReadFile(filename)
+	if len(data) != 0 {
+		t.Errorf("Expected info entry to stay buffered, got %q", data)
+	}
+
+	entry = core.GetEntry()
+	entry.Level = core.ErrorLevel
+	entry.Message = "flushed"
+	h.Handle(entry)
+
+	data, _ = os.ReadFile(filename)
+	if !strings.Contains(string(data), "buffered") || !strings.Contains(string(data), "flushed") {
+		t.Errorf("Expected error entry to flush the buffer, got %q", data)
+	}
+}
+
+func TestFileHandler_FsyncPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    FileConfig
+		writes int
+		want   uint64
+	}{
+		{"Never", FileConfig{FsyncPolicy: FsyncNever}, 4, 0},
+		{"EveryN", FileConfig{FsyncPolicy: FsyncEveryN, FsyncEvery: 2}, 5, 2},
+		{"BatchSync", FileConfig{FsyncPolicy: FsyncBatch}, 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Filename = t.TempDir() + "/test.log"
+			h, err := NewFileHandler(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close()
+
+			for i := 0; i < tt.writes; i++ {
+				entry := core.GetEntry()
+				entry.Level = core.InfoLevel
+				entry.Message = "entry"
+				h.Handle(entry)
+			}
+
+			if got := h.(*SyncFileHandler).Stats().FsyncTotal; got != tt.want {
+				t.Errorf("FsyncTotal = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileHandler_AsyncFsyncBatch(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/test.log"
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:    filename,
+		Async:       true,
+		FsyncPolicy: FsyncBatch,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "batched"
+	h.Handle(entry)
+
+	afh := h.(*AsyncFileHandler)
+	for i := 0; i < 100 && afh.Stats().FsyncTotal == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if afh.Stats().FsyncTotal == 0 {
+		t.Error("Expected an fsync after the batch")
+	}
+	data, _ := os.ReadFile(filename)
+	if !strings.Contains(string(data), "batched") {
+		t.Errorf("Expected batch to be flushed, got %q", data)
+	}
+}
+
+func TestFileHandler_CompressRotatedBackups(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/test.log"
+
+	h, err := NewFileHandler(FileConfig{
+		Filename: filename,
+		Async:    false,
+		MaxSize:  10,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"first", "second"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, _ := filepath.Glob(filename + ".*")
+	if len(matches) != 1 || !strings.HasSuffix(matches[0], ".gz") {
+		t.Fatalf("Expected a single compressed backup, got %v", matches)
+	}
+
+	f, err := os.Open(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "first") {
+		t.Errorf("Expected rotated entry in compressed backup, got %q", data)
+	}
+	if stats := h.(*SyncFileHandler).Stats(); stats.CompressedTotal != 1 || stats.CompressErrorsTotal != 0 {
+		t.Errorf("Unexpected compression stats: %+v", stats)
+	}
+}
+
+func TestFileHandler_CompressLeftoversOnStart(t *testing.T) {
+	dir := t.TempDir()
+	filename := dir + "/test.log"
+
+	stale := filename + ".2020-01-01T00-00-00.gz.tmp"
+	backup := filename + ".2020-01-01T00-00-01"
+	unrelated := filename + ".lock"
+	for _, name := range []string{stale, backup, unrelated} {
+		if err := os.WriteFile(name, []byte("data\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err := NewFileHandler(FileConfig{
+		Filename: filename,
+		Async:    true,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Expected stale temp file from a crash to be removed")
+	}
+	if _, err := os.Stat(backup + ".gz"); err != nil {
+		t.Errorf("Expected leftover backup to be compressed: %v", err)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Error("Expected uncompressed backup to be removed")
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("Expected unrelated file to be left alone: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package filehandler
+
+import (
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// FsyncPolicy defines when a file handler calls fsync on the log file
+type FsyncPolicy int
+
+const (
+	// FsyncNever leaves durability to the operating system (default)
+	FsyncNever FsyncPolicy = iota
+	// FsyncInterval flushes and fsyncs at most every FsyncInterval
+	FsyncInterval
+	// FsyncEveryN flushes and fsyncs after every FsyncEvery entries
+	FsyncEveryN
+	// FsyncBatch flushes and fsyncs after each batch drained from the
+	// async queue. Sync handlers treat every entry as a batch.
+	FsyncBatch
+)
+
+// String returns the string representation of the policy
+func (p FsyncPolicy) String() string {
+	switch p {
+	case FsyncNever:
+		return "Never"
+	case FsyncInterval:
+		return "Interval"
+	case FsyncEveryN:
+		return "EveryN"
+	case FsyncBatch:
+		return "Batch"
+	default:
+		return "Unknown"
+	}
+}
+
+// afterWrite applies the flush-on-level and per-entry fsync policies and
+// flushes every entry in shared mode.
+// Caller must hold mu.
+func (b *fileBase) afterWrite(level core.Level) error {
+	if b.fsyncPolicy == FsyncEveryN {
+		b.sinceFsync++
+		if b.sinceFsync >= b.fsyncEvery {
+			return b.flushLocked(true)
+		}
+	}
+	if b.rotationLock != nil || (b.flushOnLevel && level >= b.flushLevel) {
+		// Shared mode writes each entry with a single append
+		return b.flushLocked(false)
+	}
+	return nil
+}
+
+// endBatch flushes and fsyncs after an async batch under FsyncBatch.
+func (b *fileBase) endBatch() error {
+	if b.fsyncPolicy != FsyncBatch {
+		return nil
+	}
+	b.mu.Lock()
+	err := b.flushLocked(true)
+	b.mu.Unlock()
+	return err
+}
+
+// flushLocked flushes the buffered writer and optionally fsyncs the file,
+// recording latencies in stats. Caller must hold mu.
+func (b *fileBase) flushLocked(fsync bool) error {
+	if b.bufWriter.Buffered() > 0 {
+		start := time.Now()
+		err := b.bufWriter.Flush()
+		b.stats.ObserveFlush(time.Since(start))
+		if err != nil {
+			return err
+		}
+	}
+	if fsync {
+		start := time.Now()
+		err := b.file.Sync()
+		b.stats.ObserveFsync(time.Since(start))
+		b.sinceFsync = 0
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startFlusher starts the background goroutine for FlushInterval and
+// FsyncInterval. It stops when closed is closed; closeFile waits for it.
+func (b *fileBase) startFlusher() {
+	period := b.flushInterval
+	if b.fsyncPolicy == FsyncInterval && (period == 0 || b.fsyncInterval < period) {
+		period = b.fsyncInterval
+	}
+	if period <= 0 {
+		return
+	}
+
+	// Fsync every fsyncTicks ticks, rounding the interval up to the period
+	fsyncTicks := 0
+	if b.fsyncPolicy == FsyncInterval {
+		fsyncTicks = int((b.fsyncInterval + period - 1) / period)
+	}
+
+	b.bgWG.Add(1)
+	go func() {
+		defer b.bgWG.Done()
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		ticks := 0
+		for {
+			select {
+			case <-ticker.C:
+				ticks++
+				fsync := fsyncTicks > 0 && ticks%fsyncTicks == 0
+				b.mu.Lock()
+				err := b.flushLocked(fsync)
+				b.mu.Unlock()
+				if err != nil {
+					b.reportError(err)
+				}
+			case <-b.closed:
+				return
+			}
+		}
+	}()
+}
+
+// reportError counts err and passes it to the configured ErrorHandler.
+func (b *fileBase) reportError(err error) {
+	b.stats.IncrementErrors()
+	if b.onError != nil {
+		b.onError(err)
+	}
+}
@@ -0,0 +1,76 @@
+package filehandler
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FS is the filesystem a file handler works on. It covers exactly the
+// operations used for writing, rotation, retention and compression, so
+// tests can substitute a MemFS and inject errors.
+type FS interface {
+	OpenFile(name string, flag int, perm fs.FileMode) (File, error)
+	Stat(name string) (fs.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	MkdirAll(path string, perm fs.FileMode) error
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Glob(pattern string) ([]string, error)
+	Chtimes(name string, atime, mtime time.Time) error
+	Symlink(oldname, newname string) error
+	// SameFile reports whether fi1 and fi2 describe the same file
+	SameFile(fi1, fi2 fs.FileInfo) bool
+}
+
+// File is an open file of an FS
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+	Sync() error
+	Stat() (fs.FileInfo, error)
+}
+
+// OSFS is the FS of the operating system (default)
+type OSFS struct{}
+
+// OpenFile opens a file with os.OpenFile
+func (OSFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Stat calls os.Stat
+func (OSFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+// Rename calls os.Rename
+func (OSFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+// Remove calls os.Remove
+func (OSFS) Remove(name string) error { return os.Remove(name) }
+
+// MkdirAll calls os.MkdirAll
+func (OSFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+
+// ReadDir calls os.ReadDir
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// Glob calls filepath.Glob
+func (OSFS) Glob(pattern string) ([]string, error) { return filepath.Glob(pattern) }
+
+// Chtimes calls os.Chtimes
+func (OSFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+// Symlink calls os.Symlink
+func (OSFS) Symlink(oldname, newname string) error { return os.Symlink(oldname, newname) }
+
+// SameFile calls os.SameFile
+func (OSFS) SameFile(fi1, fi2 fs.FileInfo) bool { return os.SameFile(fi1, fi2) }
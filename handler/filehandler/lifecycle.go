@@ -0,0 +1,210 @@
+package filehandler
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// DefaultHeaderMessage is the message of the header entry written at the
+// start of every new log file
+const DefaultHeaderMessage = "log file opened"
+
+// startHooks starts the goroutine that runs OnRotate and OnBackupRemoved,
+// so slow callbacks such as uploads never stall the write path.
+func (b *fileBase) startHooks() {
+	if b.onRotate == nil && b.onBackupRemoved == nil {
+		return
+	}
+	b.hookSignal = make(chan struct{}, 1)
+
+	b.hookWG.Add(1)
+	go func() {
+		defer b.hookWG.Done()
+		for range b.hookSignal {
+			b.runHooks()
+		}
+		// Run callbacks queued right before Close
+		b.runHooks()
+	}()
+}
+
+// stopHooks waits for queued callbacks to finish. Callbacks dispatched
+// afterwards, e.g. by ApplyRetention on a closed handler, run inline.
+func (b *fileBase) stopHooks() {
+	b.hookMu.Lock()
+	if b.hookSignal == nil || b.hookStopped {
+		b.hookMu.Unlock()
+		return
+	}
+	b.hookStopped = true
+	close(b.hookSignal)
+	b.hookMu.Unlock()
+	b.hookWG.Wait()
+}
+
+// dispatchHook queues fn for the hook goroutine without blocking.
+func (b *fileBase) dispatchHook(fn func()) {
+	b.hookMu.Lock()
+	if b.hookSignal == nil || b.hookStopped {
+		b.hookMu.Unlock()
+		b.callHook(fn)
+		return
+	}
+	b.hookQueue = append(b.hookQueue, fn)
+	select {
+	case b.hookSignal <- struct{}{}:
+	default: // A run is already pending
+	}
+	b.hookMu.Unlock()
+}
+
+// runHooks runs all queued callbacks in order.
+func (b *fileBase) runHooks() {
+	b.hookMu.Lock()
+	queue := b.hookQueue
+	b.hookQueue = nil
+	b.hookMu.Unlock()
+
+	for _, fn := range queue {
+		b.callHook(fn)
+	}
+}
+
+// callHook runs a user callback, reporting a panic as an error instead of
+// crashing the hook goroutine.
+func (b *fileBase) callHook(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.reportError(fmt.Errorf("filehandler: callback panicked: %v", r))
+		}
+	}()
+	fn()
+}
+
+// notifyRotate reports a finished backup to OnRotate. Retention keeps the
+// backup until the callback has returned and runs again afterwards, so an
+// upload never finds its backup removed.
+func (b *fileBase) notifyRotate(backup string) {
+	if b.onRotate == nil {
+		return
+	}
+	b.markPending(backup)
+	active := b.activeFile()
+	b.dispatchHook(func() {
+		defer b.releasePending(backup)
+		b.onRotate(backup, active)
+	})
+}
+
+// markPending keeps backup, plain or compressed, from being removed by
+// retention until unmarkPending or releasePending.
+func (b *fileBase) markPending(backup string) {
+	if b.onRotate == nil {
+		return
+	}
+	b.hookMu.Lock()
+	if b.pendingRotate == nil {
+		b.pendingRotate = make(map[string]struct{})
+	}
+	b.pendingRotate[strings.TrimSuffix(backup, compressSuffix)] = struct{}{}
+	b.hookMu.Unlock()
+}
+
+// unmarkPending lets retention remove backup again.
+func (b *fileBase) unmarkPending(backup string) {
+	if b.onRotate == nil {
+		return
+	}
+	b.hookMu.Lock()
+	delete(b.pendingRotate, strings.TrimSuffix(backup, compressSuffix))
+	b.hookMu.Unlock()
+}
+
+// releasePending unmarks backup after its OnRotate and applies the
+// retention that was held back for it.
+func (b *fileBase) releasePending(backup string) {
+	b.unmarkPending(backup)
+	if !b.hasRetention {
+		return
+	}
+	if b.rotationLock != nil {
+		if err := b.rotationLock.lock(); err != nil {
+			b.reportError(err)
+			return
+		}
+		defer b.rotationLock.unlock()
+	}
+	if _, err := b.ApplyRetention(); err != nil {
+		b.reportError(err)
+	}
+}
+
+// isPending reports whether the OnRotate of backup has not returned yet.
+func (b *fileBase) isPending(backup backupGroup) bool {
+	if b.onRotate == nil {
+		return false
+	}
+	b.hookMu.Lock()
+	defer b.hookMu.Unlock()
+	for _, path := range backup.paths {
+		if _, ok := b.pendingRotate[strings.TrimSuffix(path, compressSuffix)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyRemoved reports a backup file removed by retention to OnBackupRemoved.
+func (b *fileBase) notifyRemoved(path string) {
+	if b.onBackupRemoved == nil {
+		return
+	}
+	b.dispatchHook(func() { b.onBackupRemoved(path) })
+}
+
+// headerFields returns the fields of the header entry: the configured
+// fields followed by version, hostname and pid.
+func headerFields(cfg FileConfig) []core.Field {
+	fields := append([]core.Field(nil), cfg.HeaderFields...)
+
+	version := cfg.AppVersion
+	if version == "" {
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+			version = info.Main.Version
+		}
+	}
+	if version != "" {
+		fields = append(fields, core.Field{Key: "version", Type: core.StringType, Str: version})
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		fields = append(fields, core.Field{Key: "hostname", Type: core.StringType, Str: hostname})
+	}
+	return append(fields, core.Field{Key: "pid", Type: core.IntType, Int64: int64(os.Getpid())})
+}
+
+// writeHeaderLocked writes the header entry to the current file. It is
+// not counted as a processed entry. Caller must hold mu.
+func (b *fileBase) writeHeaderLocked() error {
+	entry := core.GetEntry()
+	defer core.PutEntry(entry)
+	entry.Time = b.clock.Now()
+	entry.Level = core.InfoLevel
+	entry.Message = b.headerMessage
+	entry.Fields = append(entry.Fields, b.headerFields...)
+
+	data, err := b.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	n, err := b.bufWriter.Write(data)
+	b.currentSize += int64(n)
+	if err == nil && b.rotationLock != nil {
+		// Shared mode writes each entry with a single append
+		err = b.bufWriter.Flush()
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package filehandler
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+func TestFileHandler_RotationCallbacks(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+
+	var mu sync.Mutex
+	var rotated, removed []string
+	h, err := NewFileHandler(FileConfig{
+		Filename:       filename,
+		Async:          false,
+		MaxSize:        10,
+		MaxBackups:     1,
+		BackupTemplate: "{filename}.{seq}",
+		OnRotate: func(oldPath, newPath string) {
+			if newPath != filename {
+				t.Errorf("OnRotate newPath = %s, want %s", newPath, filename)
+			}
+			// Fall behind the rotations, as an upload would
+			time.Sleep(20 * time.Millisecond)
+			if _, err := os.Stat(oldPath); err != nil {
+				t.Errorf("OnRotate called before backup exists: %v", err)
+			}
+			mu.Lock()
+			rotated = append(rotated, oldPath)
+			mu.Unlock()
+		},
+		OnBackupRemoved: func(path string) {
+			mu.Lock()
+			removed = append(removed, path)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"first", "second", "third"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{filename + ".1", filename + ".2"}; strings.Join(rotated, ",") != strings.Join(want, ",") {
+		t.Errorf("OnRotate paths = %v, want %v", rotated, want)
+	}
+	if len(removed) != 1 || removed[0] != filename+".1" {
+		t.Errorf("OnBackupRemoved paths = %v, want [%s.1]", removed, filename)
+	}
+}
+
+func TestFileHandler_RotationCallbackCompressed(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+
+	done := make(chan string, 1)
+	h, err := NewFileHandler(FileConfig{
+		Filename: filename,
+		Async:    true,
+		MaxSize:  10,
+		Compress: true,
+		OnRotate: func(oldPath, newPath string) {
+			done <- oldPath
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"first", "second"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case path := <-done:
+		if !strings.HasSuffix(path, compressSuffix) {
+			t.Errorf("Expected OnRotate with the compressed backup, got %s", path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Compressed backup missing: %v", err)
+		}
+	default:
+		t.Error("Expected OnRotate to run before Close returns")
+	}
+}
+
+func TestFileHandler_Header(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:       filename,
+		Async:          false,
+		MaxSize:        10,
+		BackupTemplate: "{filename}.{seq}",
+		Header:         true,
+		AppVersion:     "1.2.3",
+		HeaderFields:   []core.Field{{Key: "service", Type: core.StringType, Str: "api"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"first", "second"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{filename + ".1", filename} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, _, _ := strings.Cut(string(data), "\n")
+		for _, want := range []string{DefaultHeaderMessage, "service=api", "version=1.2.3", "hostname=", "pid=" + strconv.Itoa(os.Getpid())} {
+			if !strings.Contains(first, want) {
+				t.Errorf("%s: header %q missing %q", filepath.Base(name), first, want)
+			}
+		}
+	}
+	if got := h.(*SyncFileHandler).Stats().ProcessedTotal; got != 2 {
+		t.Errorf("Expected header not to count as processed, got %d", got)
+	}
+}
@@ -0,0 +1,59 @@
+package filehandler
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// lockSuffix is appended to the log filename for the rotation lock file
+const lockSuffix = ".lock"
+
+// errSharedUnsupported is returned for FileConfig.Shared on platforms
+// without advisory file locks.
+var errSharedUnsupported = errors.New("filehandler: shared mode is not supported on this platform")
+
+// errSharedFS is returned for FileConfig.Shared with an FS other than
+// OSFS, since the advisory lock needs a real file.
+var errSharedFS = errors.New("filehandler: shared mode requires OSFS")
+
+// rotationLock is an advisory lock on a sidecar file, held while rotating
+// or compressing so that only one of the processes sharing a log file does
+// so at a time.
+type rotationLock struct {
+	mu   sync.Mutex // flock does not exclude goroutines sharing the descriptor
+	file *os.File
+}
+
+// openRotationLock opens or creates the lock file at path.
+func openRotationLock(path string) (*rotationLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &rotationLock{file: file}, nil
+}
+
+// lock blocks until the lock is held exclusively.
+func (l *rotationLock) lock() error {
+	l.mu.Lock()
+	if err := lockFile(l.file); err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// unlock releases the lock.
+func (l *rotationLock) unlock() error {
+	defer l.mu.Unlock()
+	return unlockFile(l.file)
+}
+
+// close closes the lock file. It is safe to call on a nil lock.
+func (l *rotationLock) close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package filehandler
+
+import "os"
+
+// sharedSupported reports whether FileConfig.Shared can be used
+const sharedSupported = false
+
+// lockFile is not supported without flock.
+func lockFile(f *os.File) error {
+	return errSharedUnsupported
+}
+
+// unlockFile is not supported without flock.
+func unlockFile(f *os.File) error {
+	return errSharedUnsupported
+}
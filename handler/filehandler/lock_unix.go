@@ -0,0 +1,26 @@
+//go:build unix
+
+package filehandler
+
+import (
+	"os"
+	"syscall"
+)
+
+// sharedSupported reports whether FileConfig.Shared can be used
+const sharedSupported = true
+
+// lockFile takes an exclusive flock on f, retrying when interrupted.
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+// unlockFile releases the flock on f.
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
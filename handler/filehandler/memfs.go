@@ -0,0 +1,504 @@
+package filehandler
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// MemFS is an in-memory FS for tests. Rotation, retention and compression
+// run against it without touching disk; Fault injects errors and Crash
+// simulates losing data that was never synced.
+//
+// Paths are cleaned with filepath.Clean and otherwise taken literally;
+// "." and "/" always exist. Creating a file requires its directory.
+type MemFS struct {
+	// Fault, if set, is called before every operation with the operation
+	// name (open, read, write, sync, close, stat, rename, remove, mkdir,
+	// readdir, glob, chtimes, symlink) and path. A non-nil error is
+	// returned instead of performing the operation.
+	Fault func(op, name string) error
+	// Clock, if set, supplies modification times, so age-based retention
+	// follows the handler's clock (default: time.Now)
+	Clock core.Clock
+
+	mu    sync.Mutex
+	files map[string]*memInode
+	dirs  map[string]bool
+	links map[string]string
+}
+
+// memInode is the content of a file, shared by all handles and names.
+type memInode struct {
+	data    []byte
+	synced  int // length of data as of the last Sync
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS creates an empty in-memory filesystem
+func NewMemFS() *MemFS {
+	return &MemFS{
+		files: make(map[string]*memInode),
+		dirs:  map[string]bool{".": true, string(filepath.Separator): true},
+		links: make(map[string]string),
+	}
+}
+
+// fault runs the Fault hook.
+func (m *MemFS) fault(op, name string) error {
+	if m.Fault == nil {
+		return nil
+	}
+	if err := m.Fault(op, name); err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
+
+// now returns the time for modification times.
+func (m *MemFS) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock.Now()
+	}
+	return time.Now()
+}
+
+// exists reports whether name is a file, directory or symlink. Caller must hold mu.
+func (m *MemFS) exists(name string) bool {
+	_, file := m.files[name]
+	_, link := m.links[name]
+	return file || link || m.dirs[name]
+}
+
+// OpenFile opens or creates a file. O_CREATE, O_EXCL, O_TRUNC and
+// O_APPEND are honoured.
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	if err := m.fault("open", name); err != nil {
+		return nil, err
+	}
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inode, ok := m.files[name]
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case !ok:
+		if !m.dirs[filepath.Dir(name)] || m.exists(name) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		inode = &memInode{mode: perm, modTime: m.now()}
+		m.files[name] = inode
+	}
+	if flag&os.O_TRUNC != 0 {
+		inode.data = nil
+		inode.synced = 0
+		inode.modTime = m.now()
+	}
+	return &memFile{fs: m, name: name, inode: inode, flag: flag}, nil
+}
+
+// Stat returns the FileInfo of a file or directory, following symlinks
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	if err := m.fault("stat", name); err != nil {
+		return nil, err
+	}
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := 0; i < 8; i++ {
+		if target, ok := m.links[name]; ok {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(name), target)
+			}
+			name = filepath.Clean(target)
+			continue
+		}
+		if inode, ok := m.files[name]; ok {
+			return inode.info(name), nil
+		}
+		if m.dirs[name] {
+			return &memFileInfo{name: filepath.Base(name), mode: fs.ModeDir | 0755}, nil
+		}
+		break
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// Rename moves a file or symlink, replacing newpath
+func (m *MemFS) Rename(oldpath, newpath string) error {
+	if err := m.fault("rename", oldpath); err != nil {
+		return err
+	}
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.dirs[filepath.Dir(newpath)] || m.dirs[newpath] {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if inode, ok := m.files[oldpath]; ok {
+		delete(m.files, oldpath)
+		delete(m.links, newpath)
+		m.files[newpath] = inode
+		return nil
+	}
+	if target, ok := m.links[oldpath]; ok {
+		delete(m.links, oldpath)
+		delete(m.files, newpath)
+		m.links[newpath] = target
+		return nil
+	}
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+}
+
+// Remove removes a file, symlink or empty directory
+func (m *MemFS) Remove(name string) error {
+	if err := m.fault("remove", name); err != nil {
+		return err
+	}
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case m.files[name] != nil:
+		delete(m.files, name)
+	case m.links[name] != "":
+		delete(m.links, name)
+	case m.dirs[name]:
+		for p := range m.paths() {
+			if filepath.Dir(p) == name && p != name {
+				return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+			}
+		}
+		delete(m.dirs, name)
+	default:
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+// MkdirAll creates a directory and all missing parents
+func (m *MemFS) MkdirAll(path string, perm fs.FileMode) error {
+	if err := m.fault("mkdir", path); err != nil {
+		return err
+	}
+	path = filepath.Clean(path)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for p := path; !m.dirs[p]; p = filepath.Dir(p) {
+		if m.files[p] != nil || m.links[p] != "" {
+			return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+		}
+		m.dirs[p] = true
+	}
+	return nil
+}
+
+// ReadDir lists a directory sorted by name
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := m.fault("readdir", name); err != nil {
+		return nil, err
+	}
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.dirs[name] {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	var entries []fs.DirEntry
+	for p := range m.paths() {
+		if p == name || filepath.Dir(p) != name {
+			continue
+		}
+		var info fs.FileInfo
+		switch {
+		case m.files[p] != nil:
+			info = m.files[p].info(p)
+		case m.dirs[p]:
+			info = &memFileInfo{name: filepath.Base(p), mode: fs.ModeDir | 0755}
+		default:
+			info = &memFileInfo{name: filepath.Base(p), mode: fs.ModeSymlink | 0777}
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Glob returns the paths matching pattern, with filepath.Match semantics
+func (m *MemFS) Glob(pattern string) ([]string, error) {
+	if err := m.fault("glob", pattern); err != nil {
+		return nil, err
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var matches []string
+	for p := range m.paths() {
+		if ok, _ := filepath.Match(pattern, p); ok {
+			matches = append(matches, p)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Chtimes sets the modification time of a file
+func (m *MemFS) Chtimes(name string, atime, mtime time.Time) error {
+	if err := m.fault("chtimes", name); err != nil {
+		return err
+	}
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inode, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	inode.modTime = mtime
+	return nil
+}
+
+// Symlink creates newname pointing at oldname
+func (m *MemFS) Symlink(oldname, newname string) error {
+	if err := m.fault("symlink", newname); err != nil {
+		return err
+	}
+	newname = filepath.Clean(newname)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.exists(newname) {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrExist}
+	}
+	if !m.dirs[filepath.Dir(newname)] {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	m.links[newname] = oldname
+	return nil
+}
+
+// SameFile reports whether fi1 and fi2 describe the same MemFS file
+func (m *MemFS) SameFile(fi1, fi2 fs.FileInfo) bool {
+	a, ok1 := fi1.(*memFileInfo)
+	b, ok2 := fi2.(*memFileInfo)
+	return ok1 && ok2 && a.inode != nil && a.inode == b.inode
+}
+
+// ReadFile returns the content of a file, for assertions in tests
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inode, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), inode.data...), nil
+}
+
+// WriteFile creates or replaces a synced file with the given modification
+// time, creating its directory, e.g. to seed backups left by an earlier run
+func (m *MemFS) WriteFile(name string, data []byte, modTime time.Time) error {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for p := filepath.Dir(name); !m.dirs[p]; p = filepath.Dir(p) {
+		m.dirs[p] = true
+	}
+	m.files[name] = &memInode{
+		data:    append([]byte(nil), data...),
+		synced:  len(data),
+		mode:    0644,
+		modTime: modTime,
+	}
+	return nil
+}
+
+// Readlink returns the target of a symlink, for assertions in tests
+func (m *MemFS) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	target, ok := m.links[filepath.Clean(name)]
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+	}
+	return target, nil
+}
+
+// Crash simulates a power loss: every file loses the data written since
+// its last Sync. Open handles stay usable.
+func (m *MemFS) Crash() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, inode := range m.files {
+		inode.data = inode.data[:inode.synced]
+	}
+}
+
+// paths returns every file, directory and symlink. Caller must hold mu.
+func (m *MemFS) paths() map[string]struct{} {
+	all := make(map[string]struct{}, len(m.files)+len(m.dirs)+len(m.links))
+	for p := range m.files {
+		all[p] = struct{}{}
+	}
+	for p := range m.dirs {
+		all[p] = struct{}{}
+	}
+	for p := range m.links {
+		all[p] = struct{}{}
+	}
+	return all
+}
+
+// info returns the FileInfo of the inode under name.
+func (i *memInode) info(name string) *memFileInfo {
+	return &memFileInfo{name: filepath.Base(name), size: int64(len(i.data)), mode: i.mode, modTime: i.modTime, inode: i}
+}
+
+// memFile is an open MemFS file.
+type memFile struct {
+	fs     *MemFS
+	name   string
+	inode  *memInode
+	flag   int
+	offset int
+	closed bool
+}
+
+// Read reads from the current offset
+func (f *memFile) Read(p []byte) (int, error) {
+	if err := f.fs.fault("read", f.name); err != nil {
+		return 0, err
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == os.O_WRONLY {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.offset >= len(f.inode.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.inode.data[f.offset:])
+	f.offset += n
+	return n, nil
+}
+
+// Write writes at the current offset, or at the end with O_APPEND
+func (f *memFile) Write(p []byte) (int, error) {
+	if err := f.fs.fault("write", f.name); err != nil {
+		return 0, err
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = len(f.inode.data)
+	}
+	if end := f.offset + len(p); end > len(f.inode.data) {
+		f.inode.data = append(f.inode.data, make([]byte, end-len(f.inode.data))...)
+	}
+	copy(f.inode.data[f.offset:], p)
+	f.offset += len(p)
+	f.inode.modTime = f.fs.now()
+	return len(p), nil
+}
+
+// Sync marks the written data as durable
+func (f *memFile) Sync() error {
+	if err := f.fs.fault("sync", f.name); err != nil {
+		return err
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.inode.synced = len(f.inode.data)
+	return nil
+}
+
+// Close closes the handle
+func (f *memFile) Close() error {
+	if err := f.fs.fault("close", f.name); err != nil {
+		return err
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
+
+// Stat returns the FileInfo of the open file, even if it was renamed or removed
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	if err := f.fs.fault("stat", f.name); err != nil {
+		return nil, err
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return nil, fs.ErrClosed
+	}
+	return f.inode.info(f.name), nil
+}
+
+// memFileInfo implements fs.FileInfo for MemFS.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	inode   *memInode
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return i.size }
+func (i *memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *memFileInfo) ModTime() time.Time { return i.modTime }
+func (i *memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memFileInfo) Sys() any           { return nil }
+
+// Compile-time checks
+var (
+	_ FS = OSFS{}
+	_ FS = (*MemFS)(nil)
+)
@@ -0,0 +1,193 @@
+package filehandler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+func TestMemFS_Rotation(t *testing.T) {
+	fsys := NewMemFS()
+	h, err := NewFileHandler(FileConfig{
+		Filename:       "/logs/app.log",
+		Async:          false,
+		MaxSize:        10,
+		MaxBackups:     2,
+		BackupTemplate: "{filename}.{seq}",
+		Compress:       true,
+		Symlink:        "/logs/current.log",
+		FS:             fsys,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"first", "second", "third", "fourth"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := fsys.Glob("/logs/app.log.*")
+	if want := "/logs/app.log.2.gz,/logs/app.log.3.gz"; strings.Join(backups, ",") != want {
+		t.Fatalf("Expected backups %s, got %v", want, backups)
+	}
+	data, _ := fsys.ReadFile("/logs/app.log.3.gz")
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, _ := io.ReadAll(zr); !strings.Contains(string(plain), "third") {
+		t.Errorf("Expected third entry in newest backup, got %q", plain)
+	}
+	if current, _ := fsys.ReadFile("/logs/app.log"); !strings.Contains(string(current), "fourth") {
+		t.Errorf("Expected fourth entry in active file, got %q", current)
+	}
+	if target, _ := fsys.Readlink("/logs/current.log"); target != "app.log" {
+		t.Errorf("Expected symlink to app.log, got %q", target)
+	}
+}
+
+func TestMemFS_FaultInjection(t *testing.T) {
+	fsys := NewMemFS()
+	errFull := errors.New("no space left on device")
+	fsys.Fault = func(op, name string) error {
+		if op == "rename" {
+			return errFull
+		}
+		return nil
+	}
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:       "/logs/app.log",
+		Async:          false,
+		MaxSize:        10,
+		BackupTemplate: "{filename}.{seq}",
+		FS:             fsys,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "first"
+	h.Handle(entry)
+
+	entry = core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "second"
+	if err := h.Handle(entry); !errors.Is(err, errFull) {
+		t.Errorf("Expected injected rename error, got %v", err)
+	}
+
+	// Once the fault clears, the next entry rotates
+	fsys.Fault = nil
+	entry = core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "third"
+	h.Handle(entry)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, _ := fsys.ReadFile("/logs/app.log.1")
+	current, _ := fsys.ReadFile("/logs/app.log")
+	if !strings.Contains(string(backup), "first") {
+		t.Errorf("Expected first entry in backup, got %q", backup)
+	}
+	if !strings.Contains(string(current), "third") {
+		t.Errorf("Expected third entry in active file, got %q", current)
+	}
+}
+
+func TestMemFS_CompressRemoveFault(t *testing.T) {
+	fsys := NewMemFS()
+	failed := false
+	fsys.Fault = func(op, name string) error {
+		if op == "remove" && name == "/logs/app.log.1" && !failed {
+			failed = true
+			return errors.New("device busy")
+		}
+		return nil
+	}
+
+	var rotated []string
+	var reported []error
+	h, err := NewFileHandler(FileConfig{
+		Filename:       "/logs/app.log",
+		Async:          false,
+		MaxSize:        10,
+		BackupTemplate: "{filename}.{seq}",
+		Compress:       true,
+		FS:             fsys,
+		OnRotate:       func(oldPath, newPath string) { rotated = append(rotated, oldPath) },
+		ErrorHandler:   func(err error) { reported = append(reported, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"first", "second"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The .gz was complete when removing the plain backup failed
+	if !failed {
+		t.Fatal("Expected the injected remove fault to trigger")
+	}
+	if _, err := fsys.Stat("/logs/app.log.1"); err == nil {
+		t.Error("Expected the plain backup to be removed on retry")
+	}
+	if want := "/logs/app.log.1.gz"; len(rotated) != 1 || rotated[0] != want {
+		t.Errorf("Expected OnRotate with %s, got %v", want, rotated)
+	}
+	if len(reported) != 0 {
+		t.Errorf("Expected no reported errors, got %v", reported)
+	}
+}
+
+func TestMemFS_Crash(t *testing.T) {
+	fsys := NewMemFS()
+	h, err := NewFileHandler(FileConfig{
+		Filename:     "/logs/app.log",
+		Async:        false,
+		FlushOnLevel: true,
+		FlushLevel:   core.InfoLevel,
+		FsyncPolicy:  FsyncEveryN,
+		FsyncEvery:   2,
+		FS:           fsys,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"first", "second", "third"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+
+	// The third entry was flushed but not yet fsynced
+	fsys.Crash()
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := fsys.ReadFile("/logs/app.log")
+	if !strings.Contains(string(data), "second") || strings.Contains(string(data), "third") {
+		t.Errorf("Expected only synced entries to survive, got %q", data)
+	}
+}
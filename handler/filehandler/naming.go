@@ -0,0 +1,253 @@
+package filehandler
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBackupTemplate names backups like app.log.2006-01-02T15-04-05
+	DefaultBackupTemplate = "{filename}.{time}"
+	// DefaultBackupTimeFormat is the layout used for {time} in backup names
+	DefaultBackupTimeFormat = "2006-01-02T15-04-05"
+)
+
+// RotationSchedule aligns rotation to wall-clock boundaries
+type RotationSchedule int
+
+const (
+	// ScheduleNone disables scheduled rotation (default)
+	ScheduleNone RotationSchedule = iota
+	// ScheduleHourly rotates every hour, RotateAt past the full hour
+	ScheduleHourly
+	// ScheduleDaily rotates every day at the time of day given by RotateAt
+	ScheduleDaily
+)
+
+// String returns the string representation of the schedule
+func (s RotationSchedule) String() string {
+	switch s {
+	case ScheduleNone:
+		return "None"
+	case ScheduleHourly:
+		return "Hourly"
+	case ScheduleDaily:
+		return "Daily"
+	default:
+		return "Unknown"
+	}
+}
+
+// next returns the first scheduled rotation strictly after now. The
+// boundary is computed in wall-clock time of loc, so a daily rotation
+// stays at the configured time of day across DST changes.
+func (s RotationSchedule) next(now time.Time, at time.Duration, loc *time.Location) time.Time {
+	now = now.In(loc)
+	y, m, d := now.Date()
+	switch s {
+	case ScheduleHourly:
+		at %= time.Hour
+		min, sec := int(at/time.Minute), int(at%time.Minute/time.Second)
+		t := time.Date(y, m, d, now.Hour(), min, sec, 0, loc)
+		if !t.After(now) {
+			t = time.Date(y, m, d, now.Hour()+1, min, sec, 0, loc)
+		}
+		return t
+	case ScheduleDaily:
+		at %= 24 * time.Hour
+		hour, min, sec := int(at/time.Hour), int(at%time.Hour/time.Minute), int(at%time.Minute/time.Second)
+		t := time.Date(y, m, d, hour, min, sec, 0, loc)
+		if !t.After(now) {
+			t = time.Date(y, m, d+1, hour, min, sec, 0, loc)
+		}
+		return t
+	default:
+		return time.Time{}
+	}
+}
+
+// backupNamer builds backup file names from a template and recognizes
+// exactly the names it produces, plain or compressed.
+//
+// Supported placeholders:
+//
+//	{filename}  base name of the log file (app.log)
+//	{name}      base name without extension (app)
+//	{ext}       extension including the dot (.log)
+//	{time}      rotation time formatted with the backup time format
+//	{seq}       sequence number, one higher than the highest existing backup
+type backupNamer struct {
+	filename   string
+	name       string
+	ext        string
+	template   string
+	timeFormat string
+	loc        *time.Location
+	re         *regexp.Regexp
+	timeIdx    int // submatch index of {time}, 0 if absent
+	seqIdx     int // submatch index of {seq}, 0 if absent
+}
+
+// backupInfo describes a file recognized as a backup.
+type backupInfo struct {
+	time       time.Time
+	seq        int
+	compressed bool
+}
+
+// newBackupNamer compiles template for backups of the log file filename.
+func newBackupNamer(filename, template, timeFormat string, loc *time.Location) (*backupNamer, error) {
+	if strings.ContainsAny(template, `/\`) {
+		return nil, fmt.Errorf("backup template %q must not contain a path separator", template)
+	}
+
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	n := &backupNamer{
+		filename:   base,
+		name:       strings.TrimSuffix(base, ext),
+		ext:        ext,
+		template:   template,
+		timeFormat: timeFormat,
+		loc:        loc,
+	}
+
+	var pattern strings.Builder
+	pattern.WriteByte('^')
+	group := 0
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(rest[:open]))
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("backup template %q has an unterminated placeholder", template)
+		}
+		placeholder := rest[open : open+end+1]
+		rest = rest[open+end+1:]
+
+		switch placeholder {
+		case "{filename}":
+			pattern.WriteString(regexp.QuoteMeta(n.filename))
+		case "{name}":
+			pattern.WriteString(regexp.QuoteMeta(n.name))
+		case "{ext}":
+			pattern.WriteString(regexp.QuoteMeta(n.ext))
+		case "{time}":
+			if n.timeIdx != 0 {
+				return nil, fmt.Errorf("backup template %q uses {time} twice", template)
+			}
+			group++
+			n.timeIdx = group
+			pattern.WriteString("(.+?)")
+		case "{seq}":
+			if n.seqIdx != 0 {
+				return nil, fmt.Errorf("backup template %q uses {seq} twice", template)
+			}
+			group++
+			n.seqIdx = group
+			pattern.WriteString("([0-9]+)")
+		default:
+			return nil, fmt.Errorf("backup template %q has unknown placeholder %s", template, placeholder)
+		}
+	}
+	if n.timeIdx == 0 && n.seqIdx == 0 {
+		return nil, fmt.Errorf("backup template %q needs {time} or {seq}", template)
+	}
+	pattern.WriteString("(" + regexp.QuoteMeta(compressSuffix) + ")?$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, err
+	}
+	n.re = re
+	return n, nil
+}
+
+// format returns the backup base name for a rotation at t with sequence seq.
+func (n *backupNamer) format(t time.Time, seq int) string {
+	r := strings.NewReplacer(
+		"{filename}", n.filename,
+		"{name}", n.name,
+		"{ext}", n.ext,
+		"{time}", t.In(n.loc).Format(n.timeFormat),
+		"{seq}", strconv.Itoa(seq),
+	)
+	return r.Replace(n.template)
+}
+
+// parse reports whether name (a base name) is a backup of the log file.
+func (n *backupNamer) parse(name string) (backupInfo, bool) {
+	m := n.re.FindStringSubmatch(name)
+	if m == nil {
+		return backupInfo{}, false
+	}
+	var info backupInfo
+	if n.timeIdx != 0 {
+		t, err := time.ParseInLocation(n.timeFormat, m[n.timeIdx], n.loc)
+		if err != nil {
+			return backupInfo{}, false
+		}
+		info.time = t
+	}
+	if n.seqIdx != 0 {
+		seq, err := strconv.Atoi(m[n.seqIdx])
+		if err != nil {
+			return backupInfo{}, false
+		}
+		info.seq = seq
+	}
+	info.compressed = m[len(m)-1] != ""
+	return info, true
+}
+
+// nextSeq returns one more than the highest sequence number in dir.
+func (n *backupNamer) nextSeq(fsys FS, dir string) int {
+	if n.seqIdx == 0 {
+		return 0
+	}
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return 1
+	}
+	highest := 0
+	for _, e := range entries {
+		if info, ok := n.parse(e.Name()); ok && info.seq > highest {
+			highest = info.seq
+		}
+	}
+	return highest + 1
+}
+
+// updateSymlink points the configured symlink at the active log file.
+// The link is created under a temp name and renamed over the old one so
+// readers never observe a missing link.
+func (b *fileBase) updateSymlink() error {
+	if b.symlink == "" {
+		return nil
+	}
+
+	target := b.filename
+	if absLink, err := filepath.Abs(b.symlink); err == nil {
+		if absFile, err := filepath.Abs(b.filename); err == nil {
+			if rel, err := filepath.Rel(filepath.Dir(absLink), absFile); err == nil {
+				target = rel
+			}
+		}
+	}
+
+	tmp := b.symlink + tmpSuffix
+	b.fs.Remove(tmp)
+	if err := b.fs.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return b.fs.Rename(tmp, b.symlink)
+}
@@ -0,0 +1,166 @@
+package filehandler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+func TestRotationSchedule_Next(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	tests := []struct {
+		name     string
+		schedule RotationSchedule
+		at       time.Duration
+		loc      *time.Location
+		now      time.Time
+		want     time.Time
+	}{
+		{
+			name:     "hourly later this hour",
+			schedule: ScheduleHourly,
+			at:       15 * time.Minute,
+			loc:      time.UTC,
+			now:      time.Date(2026, 10, 16, 14, 5, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 16, 14, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "hourly next hour",
+			schedule: ScheduleHourly,
+			loc:      time.UTC,
+			now:      time.Date(2026, 10, 16, 14, 37, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily at midnight",
+			schedule: ScheduleDaily,
+			loc:      time.UTC,
+			now:      time.Date(2026, 10, 16, 14, 37, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily exactly at boundary",
+			schedule: ScheduleDaily,
+			at:       2*time.Hour + 30*time.Minute,
+			loc:      time.UTC,
+			now:      time.Date(2026, 10, 16, 2, 30, 0, 0, time.UTC),
+			want:     time.Date(2026, 10, 17, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily in time zone",
+			schedule: ScheduleDaily,
+			loc:      zone,
+			now:      time.Date(2026, 10, 16, 21, 0, 0, 0, time.UTC), // 23:00 in zone
+			want:     time.Date(2026, 10, 17, 0, 0, 0, 0, zone),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.next(tt.now, tt.at, tt.loc)
+			if !got.Equal(tt.want) {
+				t.Errorf("next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackupNamer(t *testing.T) {
+	n, err := newBackupNamer("/var/log/app.log", "{name}-{time}{ext}", "2006-01-02", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := n.format(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), 0)
+	if name != "app-2026-10-16.log" {
+		t.Errorf("format() = %q", name)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		ok         bool
+		compressed bool
+	}{
+		{"app-2026-10-16.log", true, false},
+		{"app-2026-10-16.log.gz", true, true},
+		{"app-manual.log", false, false},
+		{"app.log.lock", false, false},
+		{"app-2026-10-16.log.gz.tmp", false, false},
+	} {
+		info, ok := n.parse(tt.name)
+		if ok != tt.ok || info.compressed != tt.compressed {
+			t.Errorf("parse(%q) = %v, %v; want %v, %v", tt.name, ok, info.compressed, tt.ok, tt.compressed)
+		}
+	}
+
+	for _, tmpl := range []string{"{filename}", "{filename}.{bogus}", "{filename}.{seq}.{seq}", "old/{filename}.{time}", "{filename}.{time"} {
+		if _, err := newBackupNamer("app.log", tmpl, DefaultBackupTimeFormat, time.UTC); err == nil {
+			t.Errorf("Expected template %q to be rejected", tmpl)
+		}
+	}
+}
+
+func TestFileHandler_SequenceBackups(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:       filename,
+		Async:          false,
+		MaxSize:        10,
+		MaxBackups:     2,
+		BackupTemplate: "{name}.{seq}{ext}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Several rotations within the same second must not overwrite each other
+	for i := 0; i < 5; i++ {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = "rotate me"
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"app.3.log", "app.4.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected backup %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"app.1.log", "app.2.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected backup %s to be removed by MaxBackups", name)
+		}
+	}
+}
+
+func TestFileHandler_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	link := filepath.Join(dir, "current")
+
+	h, err := NewFileHandler(FileConfig{
+		Filename: filename,
+		Async:    false,
+		Symlink:  link,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != "app.log" {
+		t.Errorf("Symlink target = %q, want %q", target, "app.log")
+	}
+}
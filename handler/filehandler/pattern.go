@@ -0,0 +1,532 @@
+package filehandler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// patternUnit is the granularity of a filename pattern, given by its
+// finest date directive.
+type patternUnit int
+
+const (
+	unitYear patternUnit = iota
+	unitMonth
+	unitDay
+	unitHour
+	unitMinute
+	unitSecond
+)
+
+// patternDirective describes a strftime-style directive.
+type patternDirective struct {
+	width int
+	unit  patternUnit
+}
+
+// patternDirectives lists the supported directives
+var patternDirectives = map[byte]patternDirective{
+	'Y': {4, unitYear},
+	'y': {2, unitYear},
+	'm': {2, unitMonth},
+	'd': {2, unitDay},
+	'j': {3, unitDay},
+	'H': {2, unitHour},
+	'M': {2, unitMinute},
+	'S': {2, unitSecond},
+}
+
+// patternSegment is literal text (verb == 0) or a directive.
+type patternSegment struct {
+	literal string
+	verb    byte
+}
+
+// datePattern is a Filename containing strftime-style date directives,
+// e.g. logs/%Y/app-%Y-%m-%d.log. The handler writes directly into the
+// file named for the current period; when the size limit is reached
+// within a period, a sequence number is inserted before the extension
+// (app-2026-10-16.1.log).
+//
+// Supported directives: %Y %y %m %d %j %H %M %S and %% for a literal %.
+type datePattern struct {
+	segments  []patternSegment
+	ext       string // extension the sequence number is inserted before
+	unit      patternUnit
+	loc       *time.Location
+	re        *regexp.Regexp
+	verbs     []byte // directive of each time submatch, in order
+	seqIdx    int    // submatch index of the sequence number
+	staticDir string // longest leading directory without directives
+}
+
+// isDatePattern reports whether filename contains a supported date
+// directive. Names with only other uses of '%', such as "100%.log", are
+// plain filenames, as they were before patterns existed.
+func isDatePattern(filename string) bool {
+	for i := 0; i+1 < len(filename); i++ {
+		if filename[i] != '%' {
+			continue
+		}
+		i++
+		if _, ok := patternDirectives[filename[i]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDatePattern compiles a filename pattern.
+func parseDatePattern(pattern string, loc *time.Location) (*datePattern, error) {
+	pattern = filepath.Clean(pattern)
+	p := &datePattern{loc: loc, unit: unitYear}
+
+	base := filepath.Base(pattern)
+	if ext := filepath.Ext(base); ext != base && !strings.ContainsRune(ext, '%') {
+		p.ext = ext
+	}
+
+	hasDirective := false
+	var lit strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' {
+			lit.WriteByte(c)
+			continue
+		}
+		if i+1 == len(pattern) {
+			return nil, fmt.Errorf("filename pattern %q ends with %%", pattern)
+		}
+		i++
+		verb := pattern[i]
+		if verb == '%' {
+			lit.WriteByte('%')
+			continue
+		}
+		d, ok := patternDirectives[verb]
+		if !ok {
+			return nil, fmt.Errorf("filename pattern %q has unknown directive %%%c", pattern, verb)
+		}
+		if lit.Len() > 0 {
+			p.segments = append(p.segments, patternSegment{literal: lit.String()})
+			lit.Reset()
+		}
+		if !hasDirective {
+			p.staticDir = filepath.Dir(pattern[:i-1] + "x")
+		}
+		p.segments = append(p.segments, patternSegment{verb: verb})
+		p.unit = max(p.unit, d.unit)
+		hasDirective = true
+	}
+	if lit.Len() > 0 {
+		p.segments = append(p.segments, patternSegment{literal: lit.String()})
+	}
+	if !hasDirective {
+		return nil, fmt.Errorf("filename pattern %q has no date directive", pattern)
+	}
+
+	// Build the regexp matching every file the pattern produces
+	var re strings.Builder
+	re.WriteByte('^')
+	group := 0
+	for _, s := range p.withSeq() {
+		switch {
+		case s.verb == 0:
+			re.WriteString(regexp.QuoteMeta(s.literal))
+		case s.verb == 'N':
+			re.WriteString(`(?:\.(\d+))?`)
+			group++
+			p.seqIdx = group
+		default:
+			fmt.Fprintf(&re, `(\d{%d})`, patternDirectives[s.verb].width)
+			group++
+			p.verbs = append(p.verbs, s.verb)
+		}
+	}
+	re.WriteString(`(\.gz)?$`)
+	var err error
+	if p.re, err = regexp.Compile(re.String()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// withSeq returns the segments with a sequence marker (verb 'N') inserted
+// before the extension, or at the end if there is none.
+func (p *datePattern) withSeq() []patternSegment {
+	segs := make([]patternSegment, 0, len(p.segments)+2)
+	segs = append(segs, p.segments...)
+	last := &segs[len(segs)-1]
+	if p.ext != "" && last.verb == 0 && strings.HasSuffix(last.literal, p.ext) {
+		last.literal = strings.TrimSuffix(last.literal, p.ext)
+		return append(segs, patternSegment{verb: 'N'}, patternSegment{literal: p.ext})
+	}
+	return append(segs, patternSegment{verb: 'N'})
+}
+
+// format returns the file name for t; seq > 0 adds a sequence number.
+func (p *datePattern) format(t time.Time, seq int) string {
+	t = t.In(p.loc)
+	var sb strings.Builder
+	for _, s := range p.withSeq() {
+		switch s.verb {
+		case 0:
+			sb.WriteString(s.literal)
+		case 'N':
+			if seq > 0 {
+				sb.WriteByte('.')
+				sb.WriteString(strconv.Itoa(seq))
+			}
+		case 'Y':
+			fmt.Fprintf(&sb, "%04d", t.Year())
+		case 'y':
+			fmt.Fprintf(&sb, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&sb, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&sb, "%02d", t.Day())
+		case 'j':
+			fmt.Fprintf(&sb, "%03d", t.YearDay())
+		case 'H':
+			fmt.Fprintf(&sb, "%02d", t.Hour())
+		case 'M':
+			fmt.Fprintf(&sb, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&sb, "%02d", t.Second())
+		}
+	}
+	return sb.String()
+}
+
+// glob returns a glob matching every plain file the pattern produces,
+// possibly also some it does not; parse has the final say.
+func (p *datePattern) glob() string {
+	special := `*?[`
+	if filepath.Separator != '\\' {
+		special += `\`
+	}
+	var sb strings.Builder
+	for _, s := range p.withSeq() {
+		switch s.verb {
+		case 0:
+			for _, c := range s.literal {
+				if strings.ContainsRune(special, c) {
+					sb.WriteByte('\\')
+				}
+				sb.WriteRune(c)
+			}
+		case 'N':
+			sb.WriteByte('*')
+		default:
+			sb.WriteString(strings.Repeat("[0-9]", patternDirectives[s.verb].width))
+		}
+	}
+	return sb.String()
+}
+
+// parse recognizes a path produced by the pattern and returns the start of
+// its period and its sequence number.
+func (p *datePattern) parse(path string) (backupInfo, bool) {
+	m := p.re.FindStringSubmatch(path)
+	if m == nil {
+		return backupInfo{}, false
+	}
+
+	year, month, day, hour, min, sec, yday := 0, 1, 1, 0, 0, 0, 0
+	for i, verb := range p.verbs {
+		v, _ := strconv.Atoi(m[i+1])
+		switch verb {
+		case 'Y':
+			year = v
+		case 'y':
+			year = 2000 + v
+		case 'm':
+			month = v
+		case 'd':
+			day = v
+		case 'j':
+			yday = v
+		case 'H':
+			hour = v
+		case 'M':
+			min = v
+		case 'S':
+			sec = v
+		}
+	}
+	if yday > 0 {
+		month, day = 1, yday
+	}
+
+	info := backupInfo{
+		time:       time.Date(year, time.Month(month), day, hour, min, sec, 0, p.loc),
+		compressed: m[len(m)-1] != "",
+	}
+	if s := m[p.seqIdx]; s != "" {
+		info.seq, _ = strconv.Atoi(s)
+	}
+	return info, true
+}
+
+// start returns the start of the period containing t.
+func (p *datePattern) start(t time.Time) time.Time {
+	t = t.In(p.loc)
+	y, m, d := t.Date()
+	switch p.unit {
+	case unitYear:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, p.loc)
+	case unitMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, p.loc)
+	case unitDay:
+		return time.Date(y, m, d, 0, 0, 0, 0, p.loc)
+	case unitHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, p.loc)
+	case unitMinute:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, p.loc)
+	default:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, p.loc)
+	}
+}
+
+// next returns the start of the period following the one containing t.
+func (p *datePattern) next(t time.Time) time.Time {
+	s := p.start(t)
+	y, m, d := s.Date()
+	switch p.unit {
+	case unitYear:
+		return time.Date(y+1, 1, 1, 0, 0, 0, 0, p.loc)
+	case unitMonth:
+		return time.Date(y, m+1, 1, 0, 0, 0, 0, p.loc)
+	case unitDay:
+		return time.Date(y, m, d+1, 0, 0, 0, 0, p.loc)
+	case unitHour:
+		return s.Add(time.Hour)
+	case unitMinute:
+		return s.Add(time.Minute)
+	default:
+		return s.Add(time.Second)
+	}
+}
+
+// files returns every existing file produced by the pattern, plain and
+// compressed, with its parsed info.
+func (p *datePattern) files(fsys FS) (map[string]backupInfo, error) {
+	glob := p.glob()
+	plain, err := fsys.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := fsys.Glob(glob + compressSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]backupInfo, len(plain)+len(compressed))
+	for _, path := range append(plain, compressed...) {
+		if info, ok := p.parse(path); ok {
+			files[path] = info
+		}
+	}
+	return files, nil
+}
+
+// active returns the file to write to at now: the file of the current
+// period with the highest existing sequence number, so a restart or a new
+// period keeps appending where an earlier run stopped. A file that was
+// already compressed is never appended to; the next number is used instead.
+func (p *datePattern) active(fsys FS, now time.Time) (string, int) {
+	seq, compressed := -1, -1
+	if files, err := p.files(fsys); err == nil {
+		period := p.start(now)
+		for _, info := range files {
+			switch {
+			case !info.time.Equal(period):
+			case info.compressed:
+				compressed = max(compressed, info.seq)
+			default:
+				seq = max(seq, info.seq)
+			}
+		}
+	}
+	if compressed >= seq {
+		seq = compressed + 1
+	}
+	return p.format(now, seq), seq
+}
+
+// nextSeq returns one more than the highest sequence number among the
+// plain and compressed files of the period of now.
+func (p *datePattern) nextSeq(fsys FS, now time.Time) int {
+	highest := -1
+	if files, err := p.files(fsys); err == nil {
+		period := p.start(now)
+		for _, info := range files {
+			if info.time.Equal(period) {
+				highest = max(highest, info.seq)
+			}
+		}
+	}
+	return highest + 1
+}
+
+// pruneDirs removes the now empty directories of removed files below the
+// pattern's static directory. Non-empty directories are left alone.
+func (p *datePattern) pruneDirs(fsys FS, removed []string) {
+	for _, path := range removed {
+		for dir := filepath.Dir(path); dir != p.staticDir && dir != "." && len(dir) > len(p.staticDir); dir = filepath.Dir(dir) {
+			if fsys.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+}
+
+// checkPatternConfig rejects options that rename the active file, which a
+// filename pattern replaces.
+func checkPatternConfig(cfg FileConfig) error {
+	switch {
+	case cfg.MaxAge > 0, cfg.RotateInterval > 0, cfg.RotateSchedule != ScheduleNone:
+		return fmt.Errorf("filename pattern %q rotates by date and cannot be combined with MaxAge, RotateInterval or RotateSchedule", cfg.Filename)
+	case cfg.BackupTemplate != "" && cfg.BackupTemplate != DefaultBackupTemplate:
+		return fmt.Errorf("filename pattern %q names its own files and cannot be combined with BackupTemplate", cfg.Filename)
+	case cfg.Shared:
+		return fmt.Errorf("filename pattern %q cannot be combined with Shared", cfg.Filename)
+	}
+	return nil
+}
+
+// activeFile returns the path of the file currently written. Unlike
+// filename it may be read without holding mu.
+func (b *fileBase) activeFile() string {
+	return *b.activePath.Load()
+}
+
+// rotatePattern switches to the file for the current period, or to the
+// next sequence number within the period when the size limit was reached.
+// Caller must hold mu.
+func (b *fileBase) rotatePattern() error {
+	if err := b.bufWriter.Flush(); err != nil {
+		return err
+	}
+	if err := b.file.Sync(); err != nil {
+		return err
+	}
+	if err := b.file.Close(); err != nil {
+		return err
+	}
+
+	now := b.clock.Now()
+	finished := b.filename
+	// Skip past files of the period that appeared since the last rotation,
+	// so a size rotation never appends to or overwrites one
+	seq := max(b.patternSeq+1, b.pattern.nextSeq(b.fs, now))
+	filename := b.pattern.format(now, seq)
+	if !now.Before(b.nextRotation) {
+		// Continue after the files an earlier run left for the new period
+		filename, seq = b.pattern.active(b.fs, now)
+		b.nextRotation = b.pattern.next(now)
+	}
+
+	if err := b.fs.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	file, err := b.fs.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	b.filename = filename
+	b.activePath.Store(&filename)
+	b.patternSeq = seq
+	b.file = file
+	b.sizeWriter.reset(file)
+	b.bufWriter.Reset(b.sizeWriter)
+	b.currentSize = info.Size()
+	b.lastRotateTime = now
+
+	b.markPending(finished)
+	if b.hasRetention {
+		if _, err := b.ApplyRetention(); err != nil {
+			b.reportError(err)
+		}
+	}
+	if b.compress {
+		b.signalCompressor()
+	} else {
+		b.notifyRotate(finished)
+	}
+	if err := b.updateSymlink(); err != nil {
+		b.reportError(err)
+	}
+	if b.header && b.currentSize == 0 {
+		return b.writeHeaderLocked()
+	}
+	return nil
+}
+
+// listPatternBackups returns the files of the filename pattern other than
+// the active one, oldest first.
+func (b *fileBase) listPatternBackups() ([]backupGroup, error) {
+	files, err := b.pattern.files(b.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	active := b.activeFile()
+	index := make(map[string]int)
+	var backups []backupGroup
+	for path, info := range files {
+		key := strings.TrimSuffix(path, compressSuffix)
+		if key == active {
+			continue
+		}
+		i, seen := index[key]
+		if !seen {
+			i = len(backups)
+			index[key] = i
+			backups = append(backups, backupGroup{info: info})
+		}
+		backups[i].paths = append(backups[i].paths, path)
+	}
+	for i := range backups {
+		sort.Strings(backups[i].paths) // Plain file before .gz
+	}
+
+	sortBackups(backups)
+	return backups, nil
+}
+
+// compressPatternBackups is compressBackups for a filename pattern.
+func (b *fileBase) compressPatternBackups() {
+	stale, _ := b.fs.Glob(b.pattern.glob() + compressSuffix + tmpSuffix)
+	for _, tmp := range stale {
+		if info, ok := b.pattern.parse(strings.TrimSuffix(tmp, tmpSuffix)); ok && info.compressed {
+			b.fs.Remove(tmp)
+		}
+	}
+
+	backups, err := b.listPatternBackups()
+	if err != nil {
+		b.stats.IncrementCompressErrors()
+		b.reportError(err)
+		return
+	}
+	for _, backup := range backups {
+		for _, path := range backup.paths {
+			if !strings.HasSuffix(path, compressSuffix) {
+				b.compressBackup(path)
+			}
+		}
+	}
+}
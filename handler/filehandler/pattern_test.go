@@ -0,0 +1,310 @@
+package filehandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/nlogtest"
+)
+
+func TestDatePattern(t *testing.T) {
+	p, err := parseDatePattern("logs/%Y/app-%Y-%m-%d.log", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 13, 45, 0, 0, time.UTC)
+
+	if got := p.format(now, 0); got != "logs/2026/app-2026-10-16.log" {
+		t.Errorf("format = %s", got)
+	}
+	if got := p.format(now, 2); got != "logs/2026/app-2026-10-16.2.log" {
+		t.Errorf("format with seq = %s", got)
+	}
+	if got := p.next(now); !got.Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("next = %v", got)
+	}
+
+	tests := []struct {
+		path       string
+		ok         bool
+		seq        int
+		compressed bool
+	}{
+		{"logs/2026/app-2026-10-16.log", true, 0, false},
+		{"logs/2026/app-2026-10-16.3.log", true, 3, false},
+		{"logs/2026/app-2026-10-16.1.log.gz", true, 1, true},
+		{"logs/2026/app-2026-10-16.log.bak", false, 0, false},
+		{"logs/2026/app-latest.log", false, 0, false},
+	}
+	for _, tt := range tests {
+		info, ok := p.parse(tt.path)
+		if ok != tt.ok {
+			t.Errorf("parse(%s) ok = %v, want %v", tt.path, ok, tt.ok)
+			continue
+		}
+		if ok && (info.seq != tt.seq || info.compressed != tt.compressed || !info.time.Equal(p.start(now))) {
+			t.Errorf("parse(%s) = %+v", tt.path, info)
+		}
+		if matched, _ := filepath.Match(p.glob(), strings.TrimSuffix(tt.path, compressSuffix)); ok && !matched {
+			t.Errorf("glob %s does not match %s", p.glob(), tt.path)
+		}
+	}
+
+	for _, bad := range []string{"app.log", "app-%Q.log", "app-%"} {
+		if _, err := parseDatePattern(bad, time.UTC); err == nil {
+			t.Errorf("Expected error for pattern %q", bad)
+		}
+	}
+
+	p, err = parseDatePattern("100%%/app-%d.log", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.format(now, 0); got != "100%/app-16.log" {
+		t.Errorf("format with %%%% = %s", got)
+	}
+	if _, ok := p.parse("100%/app-16.log"); !ok {
+		t.Error("Expected parse to accept a literal %")
+	}
+}
+
+func TestIsDatePattern(t *testing.T) {
+	tests := map[string]bool{
+		"app.log":            false,
+		"app-100%.log":       false,
+		"50%off-%q.log":      false,
+		"app-%%Y.log":        false,
+		"app-%":              false,
+		"app-%Y.log":         true,
+		"app-%%%d.log":       true,
+		"logs/%%/app-%H.log": true,
+	}
+	for name, want := range tests {
+		if got := isDatePattern(name); got != want {
+			t.Errorf("isDatePattern(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFileHandler_LiteralPercent(t *testing.T) {
+	fsys := NewMemFS()
+	h, err := NewFileHandler(FileConfig{Filename: "/logs/app-100%.log", Async: false, FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "literal"
+	h.Handle(entry)
+	h.Close()
+	if data, _ := fsys.ReadFile("/logs/app-100%.log"); !strings.Contains(string(data), "literal") {
+		t.Errorf("Expected a filename without directives to be used as is, got %q", data)
+	}
+}
+
+func TestFileHandler_PatternSequence(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "%Y", "app-%Y-%m-%d.log")
+
+	h, err := NewFileHandler(FileConfig{
+		Filename: pattern,
+		Async:    false,
+		MaxSize:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"first", "second", "third"} {
+		entry := core.GetEntry()
+		entry.Level = core.InfoLevel
+		entry.Message = msg
+		h.Handle(entry)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ := parseDatePattern(pattern, time.Local)
+	now := time.Now()
+	for seq, msg := range []string{"first", "second", "third"} {
+		data, err := os.ReadFile(p.format(now, seq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), msg) {
+			t.Errorf("Expected %q in file %d, got %q", msg, seq, data)
+		}
+	}
+
+	// A restart keeps appending to the newest file of the period
+	h, err = NewFileHandler(FileConfig{Filename: pattern, Async: false, MaxSize: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "restarted"
+	h.Handle(entry)
+	h.Close()
+	if data, _ := os.ReadFile(p.format(now, 2)); !strings.Contains(string(data), "restarted") {
+		t.Errorf("Expected restart to append to the newest file, got %q", data)
+	}
+}
+
+func TestFileHandler_PatternPeriodContinuesSequence(t *testing.T) {
+	clock := nlogtest.NewManualClock(time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC))
+	fsys := NewMemFS()
+	fsys.MkdirAll("/logs", 0755)
+	// Left for the next day by an earlier run
+	fsys.WriteFile("/logs/app-2024-01-02.log", []byte("earlier\n"), clock.Now())
+	fsys.WriteFile("/logs/app-2024-01-02.1.log.gz", []byte("earlier\n"), clock.Now())
+
+	h, err := NewFileHandler(FileConfig{
+		Filename: "/logs/app-%Y-%m-%d.log",
+		Async:    false,
+		Location: time.UTC,
+		FS:       fsys,
+		Clock:    clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "first day"
+	h.Handle(entry)
+	clock.Advance(2 * time.Hour)
+	entry = core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "second day"
+	h.Handle(entry)
+	h.Close()
+
+	// The compressed .1 is never appended to, so the sequence goes on at .2
+	data, _ := fsys.ReadFile("/logs/app-2024-01-02.2.log")
+	if !strings.Contains(string(data), "second day") {
+		t.Errorf("Expected the new period to continue the sequence, got %q", data)
+	}
+	if data, _ := fsys.ReadFile("/logs/app-2024-01-02.log"); strings.Contains(string(data), "second day") {
+		t.Errorf("Expected the existing files of the period to be left alone, got %q", data)
+	}
+}
+
+func TestFileHandler_PatternSkipsExistingSequence(t *testing.T) {
+	clock := nlogtest.NewManualClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	fsys := NewMemFS()
+	h, err := NewFileHandler(FileConfig{
+		Filename: "/logs/app-%Y-%m-%d.log",
+		Async:    false,
+		MaxSize:  10,
+		Location: time.UTC,
+		FS:       fsys,
+		Clock:    clock,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "first"
+	h.Handle(entry)
+	// Written by another process after the handler opened the period
+	fsys.WriteFile("/logs/app-2024-01-01.1.log.gz", []byte("other\n"), clock.Now())
+	entry = core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "second"
+	h.Handle(entry)
+	h.Close()
+
+	if data, _ := fsys.ReadFile("/logs/app-2024-01-01.1.log.gz"); string(data) != "other\n" {
+		t.Errorf("Expected the existing file to be left alone, got %q", data)
+	}
+	if data, _ := fsys.ReadFile("/logs/app-2024-01-01.2.log"); !strings.Contains(string(data), "second") {
+		t.Errorf("Expected the size rotation to skip to the next free sequence, got %q", data)
+	}
+}
+
+func TestFileHandler_PatternPeriod(t *testing.T) {
+	dir := t.TempDir()
+	h, err := NewFileHandler(FileConfig{
+		Filename: filepath.Join(dir, "app-%H%M%S.log"),
+		Async:    false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "before"
+	h.Handle(entry)
+	// Wait for the next second, and with it the next file
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	entry = core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "after"
+	h.Handle(entry)
+	h.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if len(files) != 2 {
+		t.Fatalf("Expected a file per period, got %v", files)
+	}
+}
+
+func TestFileHandler_PatternRetention(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "%Y-%m", "app-%d.log")
+	p, _ := parseDatePattern(pattern, time.Local)
+
+	now := time.Now()
+	var old []string
+	for _, days := range []int{90, 60, 30} {
+		path := p.format(now.AddDate(0, 0, -days), 0)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("old\n"), 0644)
+		old = append(old, path)
+	}
+	unrelated := filepath.Join(filepath.Dir(old[0]), "notes.txt")
+	os.WriteFile(unrelated, []byte("keep"), 0644)
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:   pattern,
+		Async:      false,
+		MaxBackups: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	report, err := h.(*SyncFileHandler).ApplyRetention()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Removed) != 2 || len(report.Kept) != 1 || report.Kept[0].Paths[0] != old[2] {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if _, err := os.Stat(old[1]); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", old[1])
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("Unrelated file was touched: %v", err)
+	}
+	if _, err := os.Stat(p.format(now, 0)); err != nil {
+		t.Errorf("Active file was touched: %v", err)
+	}
+}
+
+func TestFileHandler_PatternRejectsRename(t *testing.T) {
+	_, err := NewFileHandler(FileConfig{
+		Filename:       filepath.Join(t.TempDir(), "app-%Y.log"),
+		RotateInterval: time.Hour,
+	})
+	if err == nil {
+		t.Error("Expected error combining a filename pattern with RotateInterval")
+	}
+}
@@ -0,0 +1,118 @@
+package filehandler
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Reopen closes the log file and opens the configured filename again,
+// recreating its directory if needed. Call it after an external tool such
+// as logrotate renamed or removed the file. Data still buffered from a
+// failed write is discarded, which clears the bufio.Writer's sticky error.
+func (b *fileBase) Reopen() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reopenLocked()
+}
+
+// reopenLocked implements Reopen. Caller must hold mu.
+func (b *fileBase) reopenLocked() error {
+	if err := b.openLocked(); err != nil {
+		return err
+	}
+	if b.header && b.currentSize == 0 {
+		return b.writeHeaderLocked()
+	}
+	return nil
+}
+
+// openLocked replaces the current file with a fresh handle on filename.
+// Caller must hold mu.
+func (b *fileBase) openLocked() error {
+	if b.file != nil {
+		b.bufWriter.Flush()
+		b.file.Close()
+	}
+
+	if err := b.fs.MkdirAll(filepath.Dir(b.filename), 0755); err != nil {
+		return err
+	}
+	file, err := b.fs.OpenFile(b.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	b.file = file
+	b.sizeWriter.reset(file)
+	b.bufWriter.Reset(b.sizeWriter)
+	b.currentSize = info.Size()
+	return nil
+}
+
+// checkFile reopens the log file when the path no longer refers to the
+// open file, because it was renamed or removed, or when the file shrank
+// below what was written, because it was truncated by copytruncate.
+func (b *fileBase) checkFile() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pathInfo, err := b.fs.Stat(b.filename)
+	if err == nil {
+		openInfo, openErr := b.file.Stat()
+		flushed := b.currentSize - int64(b.bufWriter.Buffered())
+		if openErr == nil && b.fs.SameFile(openInfo, pathInfo) && pathInfo.Size() >= flushed {
+			return nil
+		}
+	}
+	return b.reopenLocked()
+}
+
+// startWatcher starts the background goroutine for ReopenCheckInterval
+// and ReopenOnSIGHUP. It stops when closed is closed; closeFile waits for it.
+func (b *fileBase) startWatcher() {
+	if b.reopenCheckInterval <= 0 && !b.reopenOnSIGHUP {
+		return
+	}
+
+	var hup chan os.Signal
+	if b.reopenOnSIGHUP {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+	}
+
+	b.bgWG.Add(1)
+	go func() {
+		defer b.bgWG.Done()
+		if hup != nil {
+			defer signal.Stop(hup)
+		}
+		var tick <-chan time.Time // nil blocks forever
+		if b.reopenCheckInterval > 0 {
+			ticker := time.NewTicker(b.reopenCheckInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-tick:
+				if err := b.checkFile(); err != nil {
+					b.reportError(err)
+				}
+			case <-hup:
+				if err := b.Reopen(); err != nil {
+					b.reportError(err)
+				}
+			case <-b.closed:
+				return
+			}
+		}
+	}()
+}
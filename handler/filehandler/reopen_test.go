@@ -0,0 +1,110 @@
+package filehandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+func TestFileHandler_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+
+	h, err := NewFileHandler(FileConfig{Filename: filename, Async: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfh := h.(*SyncFileHandler)
+
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "before"
+	h.Handle(entry)
+	// logrotate renames the file; buffered data still belongs to it
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sfh.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	entry = core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "after"
+	h.Handle(entry)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, _ := os.ReadFile(filename + ".1")
+	current, _ := os.ReadFile(filename)
+	if !strings.Contains(string(rotated), "before") || strings.Contains(string(rotated), "after") {
+		t.Errorf("Unexpected renamed file content: %q", rotated)
+	}
+	if !strings.Contains(string(current), "after") || strings.Contains(string(current), "before") {
+		t.Errorf("Unexpected reopened file content: %q", current)
+	}
+}
+
+func TestFileHandler_ReopenCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		rotate func(filename string) error
+	}{
+		{"Rename", func(filename string) error { return os.Rename(filename, filename+".1") }},
+		{"Remove", os.Remove},
+		{"CopyTruncate", func(filename string) error { return os.Truncate(filename, 0) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "test.log")
+			h, err := NewFileHandler(FileConfig{
+				Filename:            filename,
+				Async:               false,
+				FlushOnLevel:        true,
+				FlushLevel:          core.InfoLevel,
+				ReopenCheckInterval: 5 * time.Millisecond,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close()
+			sfh := h.(*SyncFileHandler)
+
+			entry := core.GetEntry()
+			entry.Level = core.InfoLevel
+			entry.Message = "before"
+			h.Handle(entry)
+			openInfo, _ := sfh.file.Stat()
+			if err := tt.rotate(filename); err != nil {
+				t.Fatal(err)
+			}
+
+			reopened := func() bool {
+				sfh.mu.Lock()
+				defer sfh.mu.Unlock()
+				info, _ := sfh.file.Stat()
+				return !os.SameFile(openInfo, info) || sfh.currentSize == 0
+			}
+			for i := 0; i < 100 && !reopened(); i++ {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if !reopened() {
+				t.Fatal("Expected the handler to reopen the file")
+			}
+
+			entry = core.GetEntry()
+			entry.Level = core.InfoLevel
+			entry.Message = "after"
+			h.Handle(entry)
+			data, _ := os.ReadFile(filename)
+			if string(data) == "" || strings.Contains(string(data), "before") {
+				t.Errorf("Expected only new entries at the path, got %q", data)
+			}
+		})
+	}
+}
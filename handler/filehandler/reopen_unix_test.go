@@ -0,0 +1,57 @@
+//go:build unix
+
+package filehandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+func TestFileHandler_ReopenOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+
+	h, err := NewFileHandler(FileConfig{
+		Filename:       filename,
+		Async:          false,
+		ReopenOnSIGHUP: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(filename); err == nil {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	entry := core.GetEntry()
+	entry.Level = core.InfoLevel
+	entry.Message = "after signal"
+	h.Handle(entry)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Expected SIGHUP to recreate the file: %v", err)
+	}
+	if !strings.Contains(string(data), "after signal") {
+		t.Errorf("Expected entry in reopened file, got %q", data)
+	}
+}
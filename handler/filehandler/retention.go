@@ -0,0 +1,141 @@
+package filehandler
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// RemovalReason explains why retention removes a backup
+type RemovalReason string
+
+const (
+	// RemovedByCount means the backup exceeds MaxBackups
+	RemovedByCount RemovalReason = "count"
+	// RemovedByAge means the backup is older than MaxBackupAge
+	RemovedByAge RemovalReason = "age"
+	// RemovedBySize means keeping the backup would exceed MaxTotalSize
+	RemovedBySize RemovalReason = "size"
+)
+
+// BackupFile describes a rotated backup on disk
+type BackupFile struct {
+	// Paths holds the plain file and/or its compressed form
+	Paths []string
+	// Time is the rotation time from the backup name, or the modification
+	// time when the name carries no timestamp
+	Time time.Time
+	// Size is the total size of Paths in bytes
+	Size int64
+	// Reason is set for backups selected for removal
+	Reason RemovalReason
+}
+
+// RetentionReport lists the backups kept and removed by a retention pass,
+// both oldest first.
+type RetentionReport struct {
+	Kept    []BackupFile
+	Removed []BackupFile
+}
+
+// PlanRetention reports which backups the retention policy would remove
+// right now without touching any file.
+func (b *fileBase) PlanRetention() (RetentionReport, error) {
+	b.retentionMu.Lock()
+	defer b.retentionMu.Unlock()
+	return b.planRetention(b.clock.Now())
+}
+
+// ApplyRetention removes the backups selected by the retention policy and
+// reports what was removed. Retention also runs after every rotation.
+func (b *fileBase) ApplyRetention() (RetentionReport, error) {
+	b.retentionMu.Lock()
+	defer b.retentionMu.Unlock()
+
+	report, err := b.planRetention(b.clock.Now())
+	if err != nil {
+		return report, err
+	}
+	var errs []error
+	var removed []string
+	for _, backup := range report.Removed {
+		for _, path := range backup.Paths {
+			if err := b.fs.Remove(path); err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			removed = append(removed, path)
+			b.notifyRemoved(path)
+		}
+	}
+	if b.pattern != nil {
+		b.pattern.pruneDirs(b.fs, removed)
+	}
+	return report, errors.Join(errs...)
+}
+
+// planRetention applies MaxBackups, MaxBackupAge and MaxTotalSize to the
+// current backups. Backups are considered newest first; once the size
+// budget is exceeded, that backup and all older ones are removed. Backups
+// whose OnRotate has not returned yet are always kept.
+func (b *fileBase) planRetention(now time.Time) (RetentionReport, error) {
+	groups, err := b.listBackups()
+	if err != nil {
+		return RetentionReport{}, err
+	}
+
+	files := make([]BackupFile, len(groups))
+	for i, g := range groups {
+		f := BackupFile{Paths: g.paths, Time: g.info.time}
+		for _, path := range g.paths {
+			info, err := b.fs.Stat(path)
+			if err != nil {
+				continue
+			}
+			f.Size += info.Size()
+			if f.Time.IsZero() {
+				f.Time = info.ModTime()
+			}
+		}
+		files[i] = f
+	}
+
+	var report RetentionReport
+	count := 0
+	var total int64
+	overBudget := false
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		switch {
+		case b.isPending(groups[i]):
+			// Kept until its OnRotate has returned; retention runs again then
+		case b.maxBackupAge > 0 && now.Sub(f.Time) > b.maxBackupAge:
+			f.Reason = RemovedByAge
+		case b.maxBackups > 0 && count >= b.maxBackups:
+			f.Reason = RemovedByCount
+		case b.maxTotalSize > 0 && (overBudget || total+f.Size > b.maxTotalSize):
+			overBudget = true
+			f.Reason = RemovedBySize
+		}
+		if f.Reason != "" {
+			report.Removed = append(report.Removed, f)
+			continue
+		}
+		count++
+		total += f.Size
+		report.Kept = append(report.Kept, f)
+	}
+
+	reverse(report.Kept)
+	reverse(report.Removed)
+	return report, nil
+}
+
+// reverse reverses s in place.
+func reverse(s []BackupFile) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
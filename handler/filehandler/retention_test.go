@@ -0,0 +1,129 @@
+package filehandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileHandler_RetentionPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     FileConfig
+		removed []int // indexes into backups, oldest first
+		reason  RemovalReason
+	}{
+		{"count", FileConfig{MaxBackups: 2}, []int{0, 1}, RemovedByCount},
+		{"age", FileConfig{MaxBackupAge: 36 * time.Hour}, []int{0, 1}, RemovedByAge},
+		{"size", FileConfig{MaxTotalSize: 250}, []int{0, 1}, RemovedBySize},
+		{"none", FileConfig{MaxBackups: 10}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "app.log")
+			// Backups aged 72h, 48h, 24h and 1h, oldest first
+			now := time.Now()
+			var backups []string
+			for _, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, 24 * time.Hour, time.Hour} {
+				path := filename + "." + now.Add(-age).Format(DefaultBackupTimeFormat)
+				if err := os.WriteFile(path, []byte(strings.Repeat("x", 100)), 0644); err != nil {
+					t.Fatal(err)
+				}
+				backups = append(backups, path)
+			}
+			unrelated := []string{filename + ".lock", filename + ".bak-manual"}
+			for _, name := range unrelated {
+				if err := os.WriteFile(name, []byte("keep"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := tt.cfg
+			cfg.Filename = filename
+			h, err := NewFileHandler(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close()
+			sfh := h.(*SyncFileHandler)
+
+			report, err := sfh.PlanRetention()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Removed) != len(tt.removed) {
+				t.Fatalf("Expected %d removals, got %+v", len(tt.removed), report.Removed)
+			}
+			for i, idx := range tt.removed {
+				got := report.Removed[i]
+				if got.Paths[0] != backups[idx] || got.Reason != tt.reason || got.Size != 100 {
+					t.Errorf("Removed[%d] = %+v, want %s (%s)", i, got, backups[idx], tt.reason)
+				}
+			}
+			if len(report.Kept)+len(report.Removed) != len(backups) {
+				t.Errorf("Expected every backup in the report, got %+v", report)
+			}
+
+			// Dry run leaves everything in place
+			for _, path := range backups {
+				if _, err := os.Stat(path); err != nil {
+					t.Errorf("PlanRetention removed %s", path)
+				}
+			}
+
+			if _, err := sfh.ApplyRetention(); err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range report.Removed {
+				if _, err := os.Stat(r.Paths[0]); !os.IsNotExist(err) {
+					t.Errorf("Expected %s to be removed", r.Paths[0])
+				}
+			}
+			for _, name := range unrelated {
+				if _, err := os.Stat(name); err != nil {
+					t.Errorf("Unrelated file %s was touched: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileHandler_RetentionCountsCompressedOnce(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	now := time.Now()
+	backups := []string{
+		filename + "." + now.Add(-48*time.Hour).Format(DefaultBackupTimeFormat),
+		filename + "." + now.Add(-24*time.Hour).Format(DefaultBackupTimeFormat),
+	}
+	for _, path := range backups {
+		if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A backup caught between compression and removal of the plain file
+	if err := os.WriteFile(backups[1]+".gz", []byte("gz"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewFileHandler(FileConfig{Filename: filename, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	report, err := h.(*SyncFileHandler).PlanRetention()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Kept) != 1 || len(report.Kept[0].Paths) != 2 {
+		t.Errorf("Expected plain and .gz to form one kept backup, got %+v", report.Kept)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Paths[0] != backups[0] {
+		t.Errorf("Expected oldest backup to be removed, got %+v", report.Removed)
+	}
+}
@@ -0,0 +1,51 @@
+package filehandler
+
+import "io/fs"
+
+// syncShared brings the handler up to date with the other processes
+// sharing the log file: it follows a rotation done by another process and
+// takes the real file size for size-based rotation. Caller must hold mu.
+func (b *fileBase) syncShared() error {
+	pathInfo, err := b.fs.Stat(b.filename)
+	if err != nil || !b.isOpenFile(pathInfo) {
+		return b.followRotation()
+	}
+	b.currentSize = pathInfo.Size() + int64(b.bufWriter.Buffered())
+	return nil
+}
+
+// rotateShared rotates under the lock shared with the other processes.
+// If another process rotated while we waited for the lock, its new file
+// is opened instead of rotating again. Caller must hold mu.
+func (b *fileBase) rotateShared() error {
+	if err := b.rotationLock.lock(); err != nil {
+		return err
+	}
+	defer b.rotationLock.unlock()
+
+	pathInfo, err := b.fs.Stat(b.filename)
+	if err != nil || !b.isOpenFile(pathInfo) {
+		return b.followRotation()
+	}
+	return b.rotate()
+}
+
+// followRotation opens the file created by another process's rotation and
+// restarts the time-based triggers, so the rotation is not repeated here.
+// Caller must hold mu.
+func (b *fileBase) followRotation() error {
+	if err := b.openLocked(); err != nil {
+		return err
+	}
+	b.lastRotateTime = b.clock.Now()
+	if b.schedule != ScheduleNone {
+		b.nextRotation = b.schedule.next(b.lastRotateTime, b.rotateAt, b.location)
+	}
+	return nil
+}
+
+// isOpenFile reports whether info describes the currently open file.
+func (b *fileBase) isOpenFile(info fs.FileInfo) bool {
+	openInfo, err := b.file.Stat()
+	return err == nil && b.fs.SameFile(openInfo, info)
+}
@@ -0,0 +1,86 @@
+//go:build unix
+
+package filehandler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+func TestFileHandler_SharedRotation(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "test.log")
+
+	// Each handler has its own descriptors, like separate processes
+	const writers = 4
+	const perWriter = 300
+	handlers := make([]*SyncFileHandler, writers)
+	for i := range handlers {
+		h, err := NewFileHandler(FileConfig{
+			Filename:       filename,
+			Async:          false,
+			MaxSize:        4096,
+			BackupTemplate: "{filename}.{seq}",
+			Shared:         true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		handlers[i] = h.(*SyncFileHandler)
+	}
+
+	var wg sync.WaitGroup
+	for w, h := range handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				entry := core.GetEntry()
+				entry.Level = core.InfoLevel
+				entry.Message = fmt.Sprintf("writer=%d seq=%d", w, i)
+				h.Handle(entry)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, h := range handlers {
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := filepath.Glob(filename + "*")
+	seen := make(map[string]bool)
+	for _, name := range files {
+		if strings.HasSuffix(name, lockSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(name, filename+".") && len(data) > 2*4096 {
+			t.Errorf("Backup %s is %d bytes, rotation was not coordinated", filepath.Base(name), len(data))
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			var w, i int
+			msg := line[strings.Index(line, "writer="):]
+			if _, err := fmt.Sscanf(msg, "writer=%d seq=%d", &w, &i); err != nil {
+				t.Fatalf("Corrupted line %q in %s", line, filepath.Base(name))
+			}
+			seen[msg] = true
+		}
+	}
+	if len(seen) != writers*perWriter {
+		t.Errorf("Expected %d distinct lines, got %d", writers*perWriter, len(seen))
+	}
+	if len(files) < 3 {
+		t.Errorf("Expected several backups, got %v", files)
+	}
+}
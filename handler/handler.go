@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"time"
 
 	"github.com/philipp01105/nlog/core"
@@ -21,6 +22,22 @@ type FastHandler interface {
 	HandleLog(t time.Time, level core.Level, msg string, loggerFields, callFields []core.Field, caller core.CallerInfo) error
 }
 
+// LoggerFieldsHandler is an optional interface that handlers can
+// implement to tell the fields given to Builder.WithFields from the rest
+// of an entry: the first n of entry.Fields are those. The logger uses it
+// instead of Handle when a call has fields of its own; on the FastHandler
+// path the builder fields are the loggerFields.
+type LoggerFieldsHandler interface {
+	HandleLoggerFields(entry *core.Entry, n int) error
+}
+
+// ContextHandler is an optional interface that handlers can implement
+// to honour a caller context. Async handlers use the context deadline
+// and cancellation to bound how long the Block overflow policy waits.
+type ContextHandler interface {
+	HandleContext(ctx context.Context, entry *core.Entry) error
+}
+
 // StatsProvider is an optional interface that handlers can implement
 // to expose runtime statistics for monitoring.
 type StatsProvider interface {
@@ -1,6 +1,7 @@
 package multihandler
 
 import (
+	"context"
 	"time"
 
 	"github.com/philipp01105/nlog/core"
@@ -92,6 +93,43 @@ func (h *MultiHandler) Handle(entry *core.Entry) error {
 	return lastErr
 }
 
+// HandleLoggerFields processes a log entry whose first n fields are the
+// builder fields by sending it to all handlers, forwarding n to children
+// that implement handler.LoggerFieldsHandler.
+func (h *MultiHandler) HandleLoggerFields(entry *core.Entry, n int) error {
+	var lastErr error
+	for _, hdlr := range h.handlers {
+		var err error
+		if fh, ok := hdlr.(handler.LoggerFieldsHandler); ok {
+			err = fh.HandleLoggerFields(entry, n)
+		} else {
+			err = hdlr.Handle(entry)
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// HandleContext processes a log entry by sending it to all handlers,
+// forwarding ctx to children that implement handler.ContextHandler.
+func (h *MultiHandler) HandleContext(ctx context.Context, entry *core.Entry) error {
+	var lastErr error
+	for _, hdlr := range h.handlers {
+		var err error
+		if ch, ok := hdlr.(handler.ContextHandler); ok {
+			err = ch.HandleContext(ctx, entry)
+		} else {
+			err = hdlr.Handle(entry)
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
 // CanRecycleEntry returns true if the caller can recycle the entry after Handle returns.
 // This is safe when all child handlers process entries synchronously.
 func (h *MultiHandler) CanRecycleEntry() bool {
@@ -0,0 +1,23 @@
+// Package otlphandler exports log entries to an OpenTelemetry collector
+// over OTLP/HTTP using the JSON encoding.
+//
+// Each entry becomes a LogRecord: the level maps to SeverityNumber and
+// SeverityText, the message is the body, call fields become attributes and the
+// caller becomes the code.* semantic convention attributes. Fields named
+// by TraceIDKey and SpanIDKey that hold valid hex IDs set the record's
+// traceId and spanId instead. Fields given to Builder.WithFields, such
+// as service.name, become the resource attributes, while fields added by
+// Logger.With and the logger name stay record attributes; records of
+// loggers with different builder fields are sent as separate resources:
+//
+//	h := otlphandler.NewOTLPHandler(otlphandler.OTLPConfig{
+//		Endpoint: "http://collector:4318/v1/logs",
+//	})
+//	log := logger.NewBuilder().WithHandler(h).
+//		WithFields(logger.String("service.name", "api")).Build()
+//
+// Entries are queued like in the other async handlers and sent in batches
+// of BatchSize, or every FlushInterval, by a background goroutine. Failed
+// requests are retried with backoff when the collector reports a
+// retryable status; Close sends what is left, bounded by DrainTimeout.
+package otlphandler
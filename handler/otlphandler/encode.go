@@ -0,0 +1,257 @@
+package otlphandler
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/internal/jsonstr"
+)
+
+// severityNumbers maps levels to OpenTelemetry SeverityNumber values.
+// Panic ranks above Fatal, so it takes the next step, FATAL2.
+var severityNumbers = [...]int{
+	core.DebugLevel: 5,
+	core.InfoLevel:  9,
+	core.WarnLevel:  13,
+	core.ErrorLevel: 17,
+	core.FatalLevel: 21,
+	core.PanicLevel: 22,
+}
+
+// encoder writes entries as OTLP JSON LogRecords, grouped by resource.
+// The resource of an entry is made of the builder fields, so entries of
+// differently configured loggers end up in separate resourceLogs.
+type encoder struct {
+	scope      []byte // ]},"scopeLogs":[{"scope":{...},"logRecords":[
+	traceIDKey string
+	spanIDKey  string
+	groups     []*resourceGroup
+	used       int // groups holding records of the current batch
+	resource   bytes.Buffer
+	body       bytes.Buffer
+}
+
+// resourceGroup holds the records of a batch that share a resource
+type resourceGroup struct {
+	resource []byte // rendered attributes
+	records  bytes.Buffer
+}
+
+// envelopeSuffix closes the logRecords, scopeLogs and resourceLogs entries
+const envelopeSuffix = `]}]}`
+
+// newEncoder renders the scope for cfg
+func newEncoder(cfg OTLPConfig) encoder {
+	var buf bytes.Buffer
+	buf.WriteString(`]},"scopeLogs":[{"scope":{"name":`)
+	jsonstr.AppendQuoted(&buf, cfg.ScopeName, 0)
+	buf.WriteString(`},"logRecords":[`)
+	return encoder{
+		scope:      buf.Bytes(),
+		traceIDKey: cfg.TraceIDKey,
+		spanIDKey:  cfg.SpanIDKey,
+	}
+}
+
+// wrap returns a request body holding the records added since the last
+// call. The result is valid until the next call.
+func (e *encoder) wrap() []byte {
+	e.body.Reset()
+	e.body.WriteString(`{"resourceLogs":[`)
+	for i, g := range e.groups[:e.used] {
+		if i > 0 {
+			e.body.WriteByte(',')
+		}
+		e.body.WriteString(`{"resource":{"attributes":[`)
+		e.body.Write(g.resource)
+		e.body.Write(e.scope)
+		e.body.Write(g.records.Bytes())
+		e.body.WriteString(envelopeSuffix)
+		g.records.Reset()
+	}
+	e.body.WriteString(`]}`)
+	e.used = 0
+	return e.body.Bytes()
+}
+
+// group returns the group for the resource rendered into e.resource
+func (e *encoder) group() *resourceGroup {
+	for _, g := range e.groups[:e.used] {
+		if bytes.Equal(g.resource, e.resource.Bytes()) {
+			return g
+		}
+	}
+	if e.used == len(e.groups) {
+		e.groups = append(e.groups, &resourceGroup{})
+	}
+	g := e.groups[e.used]
+	g.resource = append(g.resource[:0], e.resource.Bytes()...)
+	e.used++
+	return g
+}
+
+// add encodes entry as a LogRecord. The first n fields are the builder
+// fields and become the resource attributes.
+func (e *encoder) add(entry *core.Entry, n int) {
+	// Trace context may come from logger and call fields alike
+	var traceID, spanID string
+	traceIdx, spanIdx := -1, -1
+	for i, field := range entry.Fields {
+		switch {
+		case field.Key == e.traceIDKey && isHexID(field, 32):
+			traceID, traceIdx = field.Str, i
+		case field.Key == e.spanIDKey && isHexID(field, 16):
+			spanID, spanIdx = field.Str, i
+		}
+	}
+
+	e.resource.Reset()
+	count := 0
+	for i, field := range entry.Fields[:n] {
+		if i == traceIdx || i == spanIdx {
+			continue
+		}
+		if count > 0 {
+			e.resource.WriteByte(',')
+		}
+		appendAttribute(&e.resource, field)
+		count++
+	}
+	g := e.group()
+	buf := &g.records
+	if buf.Len() > 0 {
+		buf.WriteByte(',')
+	}
+
+	buf.WriteString(`{"timeUnixNano":"`)
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), entry.Time.UnixNano(), 10))
+	buf.WriteString(`","observedTimeUnixNano":"`)
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), time.Now().UnixNano(), 10))
+	buf.WriteByte('"')
+	if int(entry.Level) < len(severityNumbers) {
+		buf.WriteString(`,"severityNumber":`)
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(severityNumbers[entry.Level]), 10))
+	}
+	buf.WriteString(`,"severityText":`)
+	jsonstr.AppendQuoted(buf, entry.Level.String(), 0)
+	buf.WriteString(`,"body":{"stringValue":`)
+	jsonstr.AppendQuoted(buf, entry.Message, 0)
+	buf.WriteByte('}')
+
+	// Attributes from the call fields, minus trace context
+	buf.WriteString(`,"attributes":[`)
+	count = 0
+	for i := n; i < len(entry.Fields); i++ {
+		if i == traceIdx || i == spanIdx {
+			continue
+		}
+		if count > 0 {
+			buf.WriteByte(',')
+		}
+		appendAttribute(buf, entry.Fields[i])
+		count++
+	}
+	if entry.Caller.Defined {
+		if count > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"key":"code.file.path","value":{"stringValue":`)
+		jsonstr.AppendQuoted(buf, entry.Caller.File, 0)
+		buf.WriteString(`}},{"key":"code.line.number","value":{"intValue":"`)
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(entry.Caller.Line), 10))
+		buf.WriteString(`"}}`)
+		if entry.Caller.Function != "" {
+			buf.WriteString(`,{"key":"code.function.name","value":{"stringValue":`)
+			jsonstr.AppendQuoted(buf, entry.Caller.Function, 0)
+			buf.WriteString(`}}`)
+		}
+	}
+	buf.WriteByte(']')
+
+	if traceID != "" {
+		buf.WriteString(`,"traceId":"`)
+		appendHexID(buf, traceID)
+		buf.WriteByte('"')
+	}
+	if spanID != "" {
+		buf.WriteString(`,"spanId":"`)
+		appendHexID(buf, spanID)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('}')
+}
+
+// isHexID reports whether field is a string of n lowercase or uppercase
+// hex digits that are not all zero, as OTLP requires for trace and span IDs
+func isHexID(field core.Field, n int) bool {
+	if field.Type != core.StringType || len(field.Str) != n {
+		return false
+	}
+	zero := true
+	for i := 0; i < n; i++ {
+		c := field.Str[i]
+		switch {
+		case c == '0':
+		case '1' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+			zero = false
+		default:
+			return false
+		}
+	}
+	return !zero
+}
+
+// appendHexID writes an ID accepted by isHexID in lowercase, as OTLP/JSON
+// requires
+func appendHexID(buf *bytes.Buffer, id string) {
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if 'A' <= c && c <= 'F' {
+			c += 'a' - 'A'
+		}
+		buf.WriteByte(c)
+	}
+}
+
+// appendAttribute writes field as an OTLP KeyValue
+func appendAttribute(buf *bytes.Buffer, field core.Field) {
+	buf.WriteString(`{"key":`)
+	jsonstr.AppendQuoted(buf, field.Key, 0)
+	buf.WriteString(`,"value":{`)
+	switch field.Type {
+	case core.IntType, core.Int64Type, core.DurationType:
+		// 64-bit integers are strings in the protobuf JSON mapping
+		buf.WriteString(`"intValue":"`)
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), field.Int64, 10))
+		buf.WriteByte('"')
+	case core.Float64Type:
+		buf.WriteString(`"doubleValue":`)
+		switch {
+		case math.IsNaN(field.Float64):
+			buf.WriteString(`"NaN"`)
+		case math.IsInf(field.Float64, 1):
+			buf.WriteString(`"Infinity"`)
+		case math.IsInf(field.Float64, -1):
+			buf.WriteString(`"-Infinity"`)
+		default:
+			buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), field.Float64, 'g', -1, 64))
+		}
+	case core.BoolType:
+		buf.WriteString(`"boolValue":`)
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), field.Int64 == 1))
+	case core.TimeType:
+		buf.WriteString(`"stringValue":"`)
+		buf.Write(time.Unix(0, field.Int64).AppendFormat(buf.AvailableBuffer(), time.RFC3339Nano))
+		buf.WriteByte('"')
+	case core.StringType, core.ErrorType:
+		buf.WriteString(`"stringValue":`)
+		jsonstr.AppendQuoted(buf, field.Str, 0)
+	default:
+		buf.WriteString(`"stringValue":`)
+		jsonstr.AppendQuoted(buf, field.StringValue(), 0)
+	}
+	buf.WriteString(`}}`)
+}
@@ -0,0 +1,464 @@
+package otlphandler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/handler"
+)
+
+// ErrClosed is returned when handling an entry after Close
+var ErrClosed = errors.New("otlphandler: handler is closed")
+
+// DefaultEndpoint is the OTLP/HTTP logs endpoint of a local collector
+const DefaultEndpoint = "http://localhost:4318/v1/logs"
+
+// OTLPConfig holds configuration for the OTLP/HTTP handler
+type OTLPConfig struct {
+	// Endpoint is the collector's logs URL (default: DefaultEndpoint)
+	Endpoint string
+	// Headers are added to every request, e.g. for authentication
+	Headers map[string]string
+	// Client sends the requests (default: a client with a 10s timeout)
+	Client *http.Client
+	// ScopeName is the instrumentation scope (default: "github.com/philipp01105/nlog")
+	ScopeName string
+	// TraceIDKey names the field holding a hex trace ID (default: "trace_id")
+	TraceIDKey string
+	// SpanIDKey names the field holding a hex span ID (default: "span_id")
+	SpanIDKey string
+	// BatchSize is the number of records per request (default: 512)
+	BatchSize int
+	// FlushInterval sends a partial batch after this long (default: 1s)
+	FlushInterval time.Duration
+	// BufferSize is the size of the async queue (default: 2048)
+	BufferSize int
+	// OverflowPolicy defines per-level overflow behavior (default: uses DefaultLevelPolicy)
+	OverflowPolicy map[core.Level]handler.OverflowPolicy
+	// BlockTimeout is the timeout for blocking overflow policy (default: 100ms)
+	BlockTimeout time.Duration
+	// MaxRetries is the number of retries of a batch after a transient
+	// error: a network error or status 429, 502, 503 or 504 (default: 3)
+	MaxRetries int
+	// RetryBackoff is the initial retry delay, doubled per attempt (default: 100ms)
+	RetryBackoff time.Duration
+	// MaxRetryBackoff caps the retry delay (default: 5s)
+	MaxRetryBackoff time.Duration
+	// DrainTimeout bounds sending the remaining records on Close (default: 5s)
+	DrainTimeout time.Duration
+	// ErrorHandler is called for every failed request (optional)
+	ErrorHandler handler.ErrorHandler
+}
+
+// OTLPHandler exports entries as OpenTelemetry log records. Entries are
+// queued, encoded into batches by a background goroutine and POSTed as
+// OTLP JSON. A batch that still fails after the retries is dropped and
+// counted in Stats.
+type OTLPHandler struct {
+	endpoint        string
+	headers         map[string]string
+	client          *http.Client
+	enc             encoder
+	batchSize       int
+	flushInterval   time.Duration
+	overflowPolicy  map[core.Level]handler.OverflowPolicy
+	blockTimeout    time.Duration
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+	drainTimeout    time.Duration
+	onError         handler.ErrorHandler
+	stats           *handler.Stats
+
+	queue     chan queued
+	flushes   chan chan error
+	closed    chan struct{} // closed first by Close, rejects new entries
+	stop      chan struct{} // closed once no producer can enqueue anymore
+	closeMu   sync.RWMutex  // held shared by producers, exclusively by Close
+	closeOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	// Owned by the background goroutine
+	levels []core.Level
+}
+
+// queued is an entry waiting to be encoded. The first n fields of entry
+// are the builder fields, which make up the resource.
+type queued struct {
+	entry *core.Entry
+	n     int
+}
+
+// NewOTLPHandler creates a new OTLP/HTTP handler
+func NewOTLPHandler(cfg OTLPConfig) *OTLPHandler {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultEndpoint
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.ScopeName == "" {
+		cfg.ScopeName = "github.com/philipp01105/nlog"
+	}
+	if cfg.TraceIDKey == "" {
+		cfg.TraceIDKey = "trace_id"
+	}
+	if cfg.SpanIDKey == "" {
+		cfg.SpanIDKey = "span_id"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 2048
+	}
+	if cfg.OverflowPolicy == nil {
+		cfg.OverflowPolicy = handler.DefaultLevelPolicy()
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = 100 * time.Millisecond
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = 5 * time.Second
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 5 * time.Second
+	}
+
+	h := &OTLPHandler{
+		endpoint:        cfg.Endpoint,
+		headers:         cfg.Headers,
+		client:          cfg.Client,
+		enc:             newEncoder(cfg),
+		batchSize:       cfg.BatchSize,
+		flushInterval:   cfg.FlushInterval,
+		overflowPolicy:  cfg.OverflowPolicy,
+		blockTimeout:    cfg.BlockTimeout,
+		maxRetries:      cfg.MaxRetries,
+		retryBackoff:    cfg.RetryBackoff,
+		maxRetryBackoff: cfg.MaxRetryBackoff,
+		drainTimeout:    cfg.DrainTimeout,
+		onError:         cfg.ErrorHandler,
+		stats:           handler.NewStats(),
+		queue:           make(chan queued, cfg.BufferSize),
+		flushes:         make(chan chan error),
+		closed:          make(chan struct{}),
+		stop:            make(chan struct{}),
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	h.wg.Add(1)
+	go h.process()
+	return h
+}
+
+// HandleLog processes log data by creating a pooled Entry and sending it
+// to the async queue. The loggerFields become the resource.
+func (h *OTLPHandler) HandleLog(t time.Time, level core.Level, msg string, loggerFields, callFields []core.Field, caller core.CallerInfo) error {
+	entry := core.GetEntry()
+	entry.Time = t
+	entry.Level = level
+	entry.Message = msg
+	entry.Caller = caller
+	if len(loggerFields) > 0 {
+		entry.Fields = append(entry.Fields, loggerFields...)
+	}
+	if len(callFields) > 0 {
+		entry.Fields = append(entry.Fields, callFields...)
+	}
+	return h.enqueue(context.Background(), queued{entry, len(loggerFields)})
+}
+
+// HandleLoggerFields sends a log entry whose first n fields were given to
+// Builder.WithFields to the async queue. Those fields become the resource.
+func (h *OTLPHandler) HandleLoggerFields(entry *core.Entry, n int) error {
+	return h.enqueue(context.Background(), queued{entry, n})
+}
+
+// Handle sends a log entry to the async queue with overflow policy
+// handling. All of its fields become record attributes.
+func (h *OTLPHandler) Handle(entry *core.Entry) error {
+	return h.HandleContext(context.Background(), entry)
+}
+
+// HandleContext sends a log entry to the async queue. Under the Block
+// policy the wait is additionally bounded by the deadline and
+// cancellation of ctx.
+func (h *OTLPHandler) HandleContext(ctx context.Context, entry *core.Entry) error {
+	return h.enqueue(ctx, queued{entry: entry})
+}
+
+// enqueue sends q to the async queue according to the overflow policy
+func (h *OTLPHandler) enqueue(ctx context.Context, q queued) error {
+	entry := q.entry
+	h.closeMu.RLock()
+	defer h.closeMu.RUnlock()
+
+	select {
+	case <-h.closed:
+		return ErrClosed
+	default:
+	}
+
+	switch h.overflowPolicy[entry.Level] {
+	case handler.Block:
+		switch handler.EnqueueBlock(ctx, h.queue, q, h.blockTimeout, h.closed) {
+		case handler.BlockEnqueued:
+			return nil
+		case handler.BlockCanceled:
+			h.stats.IncrementBlocked()
+			h.stats.IncrementDropped(entry.Level)
+			return ctx.Err()
+		case handler.BlockClosed:
+			return ErrClosed
+		default:
+			h.stats.IncrementBlocked()
+			h.stats.IncrementDropped(entry.Level)
+			return nil
+		}
+
+	case handler.DropOldest:
+		for range 2 {
+			select {
+			case h.queue <- q:
+				return nil
+			default:
+			}
+			select {
+			case old := <-h.queue:
+				h.stats.IncrementDropped(old.entry.Level)
+				core.PutEntry(old.entry)
+			default:
+			}
+		}
+		h.stats.IncrementDropped(entry.Level)
+		return nil
+
+	default:
+		select {
+		case h.queue <- q:
+		default:
+			h.stats.IncrementDropped(entry.Level)
+		}
+		return nil
+	}
+}
+
+// CanRecycleEntry returns false because entries are encoded in a
+// background goroutine after Handle returns.
+func (h *OTLPHandler) CanRecycleEntry() bool {
+	return false
+}
+
+// Flush sends all queued records and waits for the requests to finish.
+// It returns the error of the last failed request, if any.
+func (h *OTLPHandler) Flush() error {
+	done := make(chan error, 1)
+	select {
+	case h.flushes <- done:
+		return <-done
+	case <-h.closed:
+		return ErrClosed
+	}
+}
+
+// Stats returns a snapshot of the handler statistics. ProcessedTotal
+// counts exported records; records of batches that could not be sent
+// are counted as dropped.
+func (h *OTLPHandler) Stats() handler.Snapshot {
+	return h.stats.GetSnapshot()
+}
+
+// Close sends the remaining records, bounded by DrainTimeout, and stops
+// the background goroutine.
+func (h *OTLPHandler) Close() error {
+	h.closeOnce.Do(func() { close(h.closed) })
+
+	// Wait for producers that passed the closed check, including those
+	// blocked in EnqueueBlock, which observe closed and return
+	h.closeMu.Lock()
+	select {
+	case <-h.stop:
+		h.closeMu.Unlock()
+		return nil
+	default:
+	}
+	close(h.stop)
+	h.closeMu.Unlock()
+
+	timer := time.AfterFunc(h.drainTimeout, h.cancel)
+	h.wg.Wait()
+	timer.Stop()
+	h.cancel()
+	return nil
+}
+
+// process encodes queued entries into batches and sends them when full,
+// on FlushInterval, on Flush and on Close.
+func (h *OTLPHandler) process() {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(h.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case q := <-h.queue:
+			h.add(q)
+		case <-ticker.C:
+			h.send()
+		case done := <-h.flushes:
+			done <- h.drain()
+		case <-h.stop:
+			// Producers can no longer enqueue, so the queue only shrinks
+			h.drain()
+			return
+		}
+	}
+}
+
+// add encodes an entry into the batch and sends the batch when full
+func (h *OTLPHandler) add(q queued) error {
+	h.enc.add(q.entry, q.n)
+	h.levels = append(h.levels, q.entry.Level)
+	core.PutEntry(q.entry)
+	if len(h.levels) >= h.batchSize {
+		return h.send()
+	}
+	return nil
+}
+
+// drain encodes and sends everything currently queued
+func (h *OTLPHandler) drain() error {
+	var err error
+	for {
+		select {
+		case q := <-h.queue:
+			if addErr := h.add(q); addErr != nil {
+				err = addErr
+			}
+		default:
+			if sendErr := h.send(); sendErr != nil {
+				err = sendErr
+			}
+			return err
+		}
+	}
+}
+
+// send posts the current batch, retrying transient failures, and resets it
+func (h *OTLPHandler) send() error {
+	if len(h.levels) == 0 {
+		return nil
+	}
+	body := h.enc.wrap()
+	err := h.post(body)
+	if err == nil {
+		h.stats.AddProcessed(uint64(len(h.levels)))
+	} else {
+		for _, level := range h.levels {
+			h.stats.IncrementDropped(level)
+		}
+	}
+	h.levels = h.levels[:0]
+	return err
+}
+
+// post sends one request body with retries
+func (h *OTLPHandler) post(body []byte) error {
+	backoff := h.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := h.postOnce(body)
+		if err == nil {
+			return nil
+		}
+		h.stats.IncrementErrors()
+		if h.onError != nil {
+			h.onError(err)
+		}
+		if attempt >= h.maxRetries || !retryable(err) {
+			return err
+		}
+		h.stats.IncrementRetries()
+		select {
+		case <-time.After(backoff):
+		case <-h.ctx.Done():
+			return err
+		}
+		backoff = min(backoff*2, h.maxRetryBackoff)
+	}
+}
+
+// postOnce sends one request
+func (h *OTLPHandler) postOnce(body []byte) error {
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &statusError{err: err} // Malformed endpoint, never retried
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	return &statusError{
+		code: resp.StatusCode,
+		err:  fmt.Errorf("otlphandler: %s: %s", resp.Status, bytes.TrimSpace(msg)),
+	}
+}
+
+// retryable reports whether a failed request may succeed when retried:
+// network errors and the statuses the OTLP specification marks retryable.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.Temporary()
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// statusError is a request rejected by the collector
+type statusError struct {
+	code int
+	err  error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+func (e *statusError) Unwrap() error { return e.err }
+
+// Temporary reports whether the request may succeed when retried
+func (e *statusError) Temporary() bool {
+	switch e.code {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,376 @@
+package otlphandler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/handler"
+	"github.com/philipp01105/nlog/logger"
+)
+
+// collector is an httptest stand-in for an OTLP/HTTP collector
+type collector struct {
+	mu       sync.Mutex
+	requests []request
+	statuses []int // served in order, then 200
+}
+
+type request struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []attribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			LogRecords []struct {
+				TimeUnixNano   string         `json:"timeUnixNano"`
+				SeverityNumber int            `json:"severityNumber"`
+				SeverityText   string         `json:"severityText"`
+				Body           map[string]any `json:"body"`
+				Attributes     []attribute    `json:"attributes"`
+				TraceID        string         `json:"traceId"`
+				SpanID         string         `json:"spanId"`
+			} `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	if len(c.statuses) > 0 {
+		status := c.statuses[0]
+		c.statuses = c.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.requests = append(c.requests, req)
+	w.Write([]byte(`{}`))
+}
+
+func (c *collector) received() []request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]request(nil), c.requests...)
+}
+
+func TestOTLPHandler_LogRecord(t *testing.T) {
+	service := core.Field{Key: "service.name", Type: core.StringType, Str: "api"}
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h := NewOTLPHandler(OTLPConfig{
+		Endpoint:      srv.URL + "/v1/logs",
+		ScopeName:     "test",
+		FlushInterval: time.Hour,
+	})
+	defer h.Close()
+
+	entry := core.GetEntry()
+	entry.Time = time.Unix(1771419600, 5)
+	entry.Level = core.WarnLevel
+	entry.Message = "slow query"
+	entry.Fields = append(entry.Fields,
+		service,
+		core.Field{Key: "trace_id", Type: core.StringType, Str: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		core.Field{Key: "span_id", Type: core.StringType, Str: "00f067aa0ba902b7"},
+		core.Field{Key: "rows", Type: core.IntType, Int64: 42},
+		core.Field{Key: "ratio", Type: core.Float64Type, Float64: 0.5},
+		core.Field{Key: "cached", Type: core.BoolType, Int64: 1},
+	)
+	entry.Caller = core.CallerInfo{File: "/src/db.go", Line: 7, Function: "db.Query", Defined: true}
+	if err := h.HandleLoggerFields(entry, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := c.received()
+	if len(reqs) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(reqs))
+	}
+	rl := reqs[0].ResourceLogs[0]
+	if len(rl.Resource.Attributes) != 1 || rl.Resource.Attributes[0].Value["stringValue"] != "api" {
+		t.Errorf("Unexpected resource attributes %+v", rl.Resource.Attributes)
+	}
+	if rl.ScopeLogs[0].Scope.Name != "test" {
+		t.Errorf("Expected scope test, got %q", rl.ScopeLogs[0].Scope.Name)
+	}
+	rec := rl.ScopeLogs[0].LogRecords[0]
+	if rec.TimeUnixNano != "1771419600000000005" {
+		t.Errorf("timeUnixNano = %s", rec.TimeUnixNano)
+	}
+	if rec.SeverityNumber != 13 || rec.SeverityText != "WARN" {
+		t.Errorf("severity = %d %s, want 13 WARN", rec.SeverityNumber, rec.SeverityText)
+	}
+	if rec.Body["stringValue"] != "slow query" {
+		t.Errorf("body = %v", rec.Body)
+	}
+	if rec.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || rec.SpanID != "00f067aa0ba902b7" {
+		t.Errorf("trace context = %q %q", rec.TraceID, rec.SpanID)
+	}
+
+	got := map[string]any{}
+	for _, a := range rec.Attributes {
+		for _, v := range a.Value {
+			got[a.Key] = v
+		}
+	}
+	want := map[string]any{
+		"rows":               "42",
+		"ratio":              0.5,
+		"cached":             true,
+		"code.file.path":     "/src/db.go",
+		"code.line.number":   "7",
+		"code.function.name": "db.Query",
+	}
+	if len(got) != len(want) {
+		t.Errorf("attributes = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("attribute %s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestOTLPHandler_ResourceFromLoggerFields(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h := NewOTLPHandler(OTLPConfig{Endpoint: srv.URL + "/v1/logs", FlushInterval: time.Hour})
+	defer h.Close()
+
+	api := logger.NewBuilder().WithHandler(h).WithFields(logger.String("service.name", "api")).Build()
+	worker := logger.NewBuilder().WithHandler(h).WithFields(logger.String("service.name", "worker")).Build()
+	api.Info("fast path")
+	worker.Info("job done", logger.Int("jobs", 3))
+	api.Info("request", logger.String("path", "/"))
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := c.received()
+	if len(reqs) != 1 || len(reqs[0].ResourceLogs) != 2 {
+		t.Fatalf("Expected 1 request with 2 resources, got %+v", reqs)
+	}
+	want := []struct {
+		service string
+		records []string
+	}{
+		{"api", []string{"fast path", "request"}},
+		{"worker", []string{"job done"}},
+	}
+	for i, rl := range reqs[0].ResourceLogs {
+		attrs := rl.Resource.Attributes
+		if len(attrs) != 1 || attrs[0].Key != "service.name" || attrs[0].Value["stringValue"] != want[i].service {
+			t.Errorf("resource %d attributes = %+v, want service.name=%s", i, attrs, want[i].service)
+		}
+		records := rl.ScopeLogs[0].LogRecords
+		if len(records) != len(want[i].records) {
+			t.Fatalf("resource %d has %d records, want %d", i, len(records), len(want[i].records))
+		}
+		for j, rec := range records {
+			if rec.Body["stringValue"] != want[i].records[j] {
+				t.Errorf("resource %d record %d body = %v, want %s", i, j, rec.Body, want[i].records[j])
+			}
+			for _, a := range rec.Attributes {
+				if a.Key == "service.name" {
+					t.Errorf("Expected service.name only in the resource, got it in record %q", want[i].records[j])
+				}
+			}
+		}
+	}
+}
+
+func TestOTLPHandler_Batching(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h := NewOTLPHandler(OTLPConfig{
+		Endpoint:      srv.URL + "/v1/logs",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		h.Handle(&core.Entry{Time: time.Now(), Level: core.InfoLevel, Message: msg})
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for _, req := range c.received() {
+		sizes = append(sizes, len(req.ResourceLogs[0].ScopeLogs[0].LogRecords))
+	}
+	if len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Errorf("Expected batches of 2, 2 and 1, got %v", sizes)
+	}
+	if got := h.Stats().ProcessedTotal; got != 5 {
+		t.Errorf("Expected 5 processed, got %d", got)
+	}
+	if err := h.Handle(core.GetEntry()); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestOTLPHandler_FlushInterval(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h := NewOTLPHandler(OTLPConfig{
+		Endpoint:      srv.URL + "/v1/logs",
+		FlushInterval: 10 * time.Millisecond,
+	})
+	defer h.Close()
+	h.Handle(&core.Entry{Time: time.Now(), Level: core.InfoLevel, Message: "tick"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(c.received()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the partial batch to be sent after FlushInterval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOTLPHandler_Retry(t *testing.T) {
+	c := &collector{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	var errs []error
+	h := NewOTLPHandler(OTLPConfig{
+		Endpoint:      srv.URL + "/v1/logs",
+		FlushInterval: time.Hour,
+		RetryBackoff:  time.Millisecond,
+		ErrorHandler:  func(err error) { errs = append(errs, err) },
+	})
+	defer h.Close()
+
+	h.Handle(&core.Entry{Time: time.Now(), Level: core.ErrorLevel, Message: "retried"})
+	if err := h.Flush(); err != nil {
+		t.Fatalf("Expected the batch to succeed after retries, got %v", err)
+	}
+	stats := h.Stats()
+	if len(c.received()) != 1 || stats.RetriesTotal != 2 || stats.ProcessedTotal != 1 || len(errs) != 2 {
+		t.Errorf("Expected 2 retries then success, got %d requests, stats %+v, errors %v", len(c.received()), stats, errs)
+	}
+
+	// Client errors are permanent: the batch is dropped without retrying
+	c.mu.Lock()
+	c.statuses = []int{http.StatusBadRequest}
+	c.mu.Unlock()
+	h.Handle(&core.Entry{Time: time.Now(), Level: core.ErrorLevel, Message: "rejected"})
+	if err := h.Flush(); err == nil {
+		t.Fatal("Expected an error for a rejected batch")
+	}
+	stats = h.Stats()
+	if stats.RetriesTotal != 2 || stats.DroppedTotal[core.ErrorLevel] != 1 {
+		t.Errorf("Expected the rejected batch to be dropped without retry, got %+v", stats)
+	}
+}
+
+var (
+	_ handler.StatsProvider       = (*OTLPHandler)(nil)
+	_ handler.LoggerFieldsHandler = (*OTLPHandler)(nil)
+)
+
+func TestOTLPHandler_WithFieldsStayAttributes(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h := NewOTLPHandler(OTLPConfig{Endpoint: srv.URL + "/v1/logs", FlushInterval: time.Hour})
+	defer h.Close()
+
+	api := logger.NewBuilder().WithHandler(h).WithName("api").
+		WithFields(logger.String("service.name", "api")).Build()
+	api.With(logger.String("request_id", "r1")).Info("fast path")
+	api.With(logger.String("request_id", "r2")).Named("db").Info("query", logger.Int("rows", 3))
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := c.received()
+	if len(reqs) != 1 || len(reqs[0].ResourceLogs) != 1 {
+		t.Fatalf("Expected 1 request with 1 resource, got %+v", reqs)
+	}
+	rl := reqs[0].ResourceLogs[0]
+	if attrs := rl.Resource.Attributes; len(attrs) != 1 || attrs[0].Key != "service.name" {
+		t.Errorf("Expected only service.name in the resource, got %+v", attrs)
+	}
+	want := []map[string]string{
+		{"request_id": "r1", core.LoggerNameKey: "api"},
+		{"request_id": "r2", core.LoggerNameKey: "api.db"},
+	}
+	records := rl.ScopeLogs[0].LogRecords
+	if len(records) != len(want) {
+		t.Fatalf("Expected %d records, got %d", len(want), len(records))
+	}
+	for i, rec := range records {
+		got := map[string]any{}
+		for _, a := range rec.Attributes {
+			got[a.Key] = a.Value["stringValue"]
+		}
+		for k, v := range want[i] {
+			if got[k] != v {
+				t.Errorf("record %d attribute %s = %v, want %s", i, k, got[k], v)
+			}
+		}
+	}
+}
+
+func TestOTLPHandler_UppercaseTraceContext(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+	h := NewOTLPHandler(OTLPConfig{Endpoint: srv.URL + "/v1/logs", FlushInterval: time.Hour})
+	defer h.Close()
+
+	h.Handle(&core.Entry{
+		Time:    time.Now(),
+		Level:   core.InfoLevel,
+		Message: "traced",
+		Fields: []core.Field{
+			{Key: "trace_id", Type: core.StringType, Str: "4BF92F3577B34DA6A3CE929D0E0E4736"},
+			{Key: "span_id", Type: core.StringType, Str: "00F067AA0BA902B7"},
+		},
+	})
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := c.received()
+	if len(reqs) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(reqs))
+	}
+	rec := reqs[0].ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if rec.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || rec.SpanID != "00f067aa0ba902b7" {
+		t.Errorf("Expected lowercase trace context, got %q %q", rec.TraceID, rec.SpanID)
+	}
+}
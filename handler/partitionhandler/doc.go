@@ -0,0 +1,19 @@
+// Package partitionhandler provides a handler that writes each entry to a
+// file chosen by its field values or logger name, e.g. one rotating file
+// per tenant.
+//
+// The target path comes from a template whose placeholders name entry
+// fields; {logger} names the logger name:
+//
+//	h, err := partitionhandler.NewPartitionHandler(partitionhandler.PartitionConfig{
+//		PathTemplate: "/var/log/tenants/{tenant}/app.log",
+//		File:         filehandler.FileConfig{MaxSize: 100 << 20, MaxBackups: 5},
+//		MaxOpen:      200,
+//		IdleTimeout:  10 * time.Minute,
+//	})
+//
+// Partition files are filehandler handlers opened lazily on first use. At
+// most MaxOpen of them are kept open; the least recently used is closed
+// when another is needed, and partitions idle for IdleTimeout are closed
+// in the background. Opening a file only blocks writers of that partition.
+package partitionhandler
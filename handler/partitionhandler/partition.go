@@ -0,0 +1,298 @@
+package partitionhandler
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/handler"
+	"github.com/philipp01105/nlog/handler/filehandler"
+)
+
+// ErrClosed is returned when handling an entry after Close
+var ErrClosed = errors.New("partitionhandler: handler is closed")
+
+// PartitionConfig holds configuration for the partitioned file handler
+type PartitionConfig struct {
+	// PathTemplate is the file path with placeholders naming entry fields,
+	// e.g. "logs/{tenant}/app.log". The {logger} placeholder is the logger
+	// name set with Builder.WithName or Logger.Named (core.LoggerNameKey).
+	// Values are sanitized to a single path element, so they cannot escape
+	// the template's directories.
+	PathTemplate string
+	// File configures each partition file; its Filename is ignored
+	File filehandler.FileConfig
+	// DefaultValue replaces a placeholder whose field is missing or empty
+	// (default: "default")
+	DefaultValue string
+	// MaxOpen caps the number of open partition files; the least recently
+	// used one is closed when another is needed (default: 100)
+	MaxOpen int
+	// IdleTimeout closes partition files that were not written for this
+	// long (0 = keep open until evicted or Close)
+	IdleTimeout time.Duration
+}
+
+// partition is an open partition file. mu is held shared while writing and
+// exclusively while opening or closing, so an entry is never written to a
+// file that is not open.
+type partition struct {
+	path     string
+	h        handler.Handler
+	fast     handler.FastHandler
+	elem     *list.Element
+	lastUsed time.Time
+	mu       sync.RWMutex
+	closed   bool
+	err      error // set when the file could not be opened
+}
+
+// PartitionHandler writes each entry to a file chosen by its field values
+type PartitionHandler struct {
+	template     *pathTemplate
+	fileConfig   filehandler.FileConfig
+	defaultValue string
+	maxOpen      int
+	idleTimeout  time.Duration
+
+	mu         sync.Mutex
+	partitions map[string]*partition
+	lru        *list.List // front is most recently used
+	closed     bool
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewPartitionHandler creates a new partitioned file handler
+func NewPartitionHandler(cfg PartitionConfig) (*PartitionHandler, error) {
+	template, err := parsePathTemplate(cfg.PathTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DefaultValue == "" {
+		cfg.DefaultValue = "default"
+	}
+	if cfg.MaxOpen <= 0 {
+		cfg.MaxOpen = 100
+	}
+
+	h := &PartitionHandler{
+		template:     template,
+		fileConfig:   cfg.File,
+		defaultValue: sanitize(cfg.DefaultValue, "_"),
+		maxOpen:      cfg.MaxOpen,
+		idleTimeout:  cfg.IdleTimeout,
+		partitions:   make(map[string]*partition),
+		lru:          list.New(),
+		stop:         make(chan struct{}),
+	}
+	if h.idleTimeout > 0 {
+		h.wg.Add(1)
+		go h.closeIdle()
+	}
+	return h, nil
+}
+
+// HandleLog processes log data directly, forwarding it to the partition's
+// FastHandler without requiring a pooled Entry.
+func (h *PartitionHandler) HandleLog(t time.Time, level core.Level, msg string, loggerFields, callFields []core.Field, caller core.CallerInfo) error {
+	p, err := h.acquire(h.template.expand(h.defaultValue, loggerFields, callFields))
+	if err != nil {
+		return err
+	}
+	defer p.mu.RUnlock()
+
+	if p.fast != nil {
+		return p.fast.HandleLog(t, level, msg, loggerFields, callFields, caller)
+	}
+	entry := core.GetEntry()
+	entry.Time = t
+	entry.Level = level
+	entry.Message = msg
+	entry.Caller = caller
+	entry.Fields = append(entry.Fields, loggerFields...)
+	entry.Fields = append(entry.Fields, callFields...)
+	err = p.h.Handle(entry)
+	if h.CanRecycleEntry() {
+		core.PutEntry(entry)
+	}
+	return err
+}
+
+// Handle writes a log entry to its partition file
+func (h *PartitionHandler) Handle(entry *core.Entry) error {
+	p, err := h.acquire(h.template.expand(h.defaultValue, entry.Fields))
+	if err != nil {
+		return err
+	}
+	defer p.mu.RUnlock()
+	return p.h.Handle(entry)
+}
+
+// CanRecycleEntry returns true when partition files are written
+// synchronously, so entries are no longer referenced after Handle returns.
+func (h *PartitionHandler) CanRecycleEntry() bool {
+	return !h.fileConfig.Async
+}
+
+// Partitions returns the number of currently open partition files
+func (h *PartitionHandler) Partitions() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.partitions)
+}
+
+// acquire returns the open partition for path with its mu held shared,
+// opening the file and evicting the least recently used one if needed.
+// Files are opened and closed without holding mu, so a slow open only
+// delays writers of the same partition.
+func (h *PartitionHandler) acquire(path string) (*partition, error) {
+	for {
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			return nil, ErrClosed
+		}
+		p, ok := h.partitions[path]
+		var victim *partition
+		if ok {
+			h.lru.MoveToFront(p.elem)
+		} else {
+			// Publish the partition locked; writers of the same path
+			// wait on its mu until the file is open
+			p = &partition{path: path}
+			p.mu.Lock()
+			p.elem = h.lru.PushFront(p)
+			h.partitions[path] = p
+			if h.lru.Len() > h.maxOpen {
+				victim = h.removeLocked(h.lru.Back().Value.(*partition))
+			}
+		}
+		p.lastUsed = time.Now()
+		h.mu.Unlock()
+
+		if !ok {
+			h.open(p)
+		}
+		if victim != nil {
+			h.closePartition(victim)
+		}
+
+		p.mu.RLock()
+		if !p.closed {
+			return p, nil
+		}
+		err := p.err
+		p.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		// Evicted between lookup and lock; open it again
+	}
+}
+
+// open opens the file of a new partition and releases its mu, which the
+// caller holds exclusively. A partition that fails to open is removed and
+// reports the error to the writers waiting for it.
+func (h *PartitionHandler) open(p *partition) {
+	defer p.mu.Unlock()
+
+	cfg := h.fileConfig
+	cfg.Filename = p.path
+	fh, err := filehandler.NewFileHandler(cfg)
+	if err != nil {
+		p.closed = true
+		p.err = fmt.Errorf("partitionhandler: open %s: %w", p.path, err)
+		h.mu.Lock()
+		if h.partitions[p.path] == p {
+			h.removeLocked(p)
+		}
+		h.mu.Unlock()
+		return
+	}
+	p.h = fh
+	p.fast, _ = fh.(handler.FastHandler)
+}
+
+// removeLocked removes p from the open partitions. Caller must hold mu.
+func (h *PartitionHandler) removeLocked(p *partition) *partition {
+	h.lru.Remove(p.elem)
+	delete(h.partitions, p.path)
+	return p
+}
+
+// closePartition closes p once in-flight writes have finished.
+func (h *PartitionHandler) closePartition(p *partition) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil // Failed to open
+	}
+	p.closed = true
+	err := p.h.Close()
+	if err != nil && h.fileConfig.ErrorHandler != nil {
+		h.fileConfig.ErrorHandler(err)
+	}
+	return err
+}
+
+// closeIdle periodically closes partitions idle for longer than idleTimeout.
+func (h *PartitionHandler) closeIdle() {
+	defer h.wg.Done()
+	ticker := time.NewTicker(max(h.idleTimeout/2, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			var idle []*partition
+			h.mu.Lock()
+			// Least recently used partitions are at the back
+			for e := h.lru.Back(); e != nil; {
+				p := e.Value.(*partition)
+				if now.Sub(p.lastUsed) < h.idleTimeout {
+					break
+				}
+				e = e.Prev()
+				idle = append(idle, h.removeLocked(p))
+			}
+			h.mu.Unlock()
+			for _, p := range idle {
+				h.closePartition(p)
+			}
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+// Close closes all partition files
+func (h *PartitionHandler) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	open := make([]*partition, 0, len(h.partitions))
+	for e := h.lru.Front(); e != nil; e = e.Next() {
+		open = append(open, e.Value.(*partition))
+	}
+	h.partitions = nil
+	h.lru.Init()
+	h.mu.Unlock()
+
+	close(h.stop)
+	h.wg.Wait()
+
+	var errs []error
+	for _, p := range open {
+		if err := h.closePartition(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
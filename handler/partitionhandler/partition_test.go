@@ -0,0 +1,217 @@
+package partitionhandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/handler/filehandler"
+	"github.com/philipp01105/nlog/logger"
+)
+
+func TestPartitionHandler_Routing(t *testing.T) {
+	dir := t.TempDir()
+	h, err := NewPartitionHandler(PartitionConfig{
+		PathTemplate: filepath.Join(dir, "{tenant}", "app.log"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "for acme", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "acme"}}})
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "for globex", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "globex"}}})
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "for nobody"})
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "for attacker", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "../../escape"}}})
+	h.HandleLog(time.Now(), core.InfoLevel, "fast path", []core.Field{{Key: "tenant", Type: core.StringType, Str: "acme"}}, nil, core.CallerInfo{})
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	acme, _ := os.ReadFile(filepath.Join(dir, "acme", "app.log"))
+	if !strings.Contains(string(acme), "for acme") || !strings.Contains(string(acme), "fast path") || strings.Contains(string(acme), "globex") {
+		t.Errorf("Unexpected acme file: %q", acme)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "globex", "app.log")); !strings.Contains(string(got), "for globex") {
+		t.Errorf("Unexpected globex file: %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "default", "app.log")); !strings.Contains(string(got), "for nobody") {
+		t.Errorf("Expected missing field to use the default partition, got %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, ".._.._escape", "app.log")); !strings.Contains(string(got), "for attacker") {
+		t.Errorf("Expected path separators to be sanitized, got %q", got)
+	}
+
+	if err := h.Handle(&core.Entry{Level: core.InfoLevel, Message: "late", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "acme"}}}); err != ErrClosed {
+		t.Errorf("Handle after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestPartitionHandler_MaxOpen(t *testing.T) {
+	dir := t.TempDir()
+	h, err := NewPartitionHandler(PartitionConfig{
+		PathTemplate: filepath.Join(dir, "{tenant}.log"),
+		MaxOpen:      2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "first a", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "a"}}})
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "first b", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "b"}}})
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "second a", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "a"}}}) // a is now most recently used
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "first c", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "c"}}})  // evicts b
+
+	if got := h.Partitions(); got != 2 {
+		t.Errorf("Expected 2 open partitions, got %d", got)
+	}
+	// The evicted partition was closed and flushed
+	if got, _ := os.ReadFile(filepath.Join(dir, "b.log")); !strings.Contains(string(got), "first b") {
+		t.Errorf("Expected evicted partition to be flushed, got %q", got)
+	}
+
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "second b", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "b"}}})
+	h.Close()
+	if got, _ := os.ReadFile(filepath.Join(dir, "b.log")); strings.Count(string(got), " b") != 2 {
+		t.Errorf("Expected reopened partition to append, got %q", got)
+	}
+}
+
+func TestPartitionHandler_IdleTimeout(t *testing.T) {
+	dir := t.TempDir()
+	h, err := NewPartitionHandler(PartitionConfig{
+		PathTemplate: filepath.Join(dir, "{tenant}.log"),
+		IdleTimeout:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Handle(&core.Entry{Level: core.InfoLevel, Message: "idle soon", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "a"}}})
+	for i := 0; i < 100 && h.Partitions() > 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := h.Partitions(); got != 0 {
+		t.Fatalf("Expected idle partition to be closed, got %d open", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "a.log")); !strings.Contains(string(got), "idle soon") {
+		t.Errorf("Expected idle partition to be flushed, got %q", got)
+	}
+}
+
+func TestPartitionHandler_ConcurrentEviction(t *testing.T) {
+	dir := t.TempDir()
+	h, err := NewPartitionHandler(PartitionConfig{
+		PathTemplate: filepath.Join(dir, "{tenant}.log"),
+		MaxOpen:      2,
+		File:         filehandler.FileConfig{Async: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := []string{"a", "b", "c", "d"}
+	var wg sync.WaitGroup
+	for _, tenant := range tenants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				h.Handle(&core.Entry{Level: core.InfoLevel, Message: "line", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: tenant}}})
+			}
+		}()
+	}
+	wg.Wait()
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tenant := range tenants {
+		data, _ := os.ReadFile(filepath.Join(dir, tenant+".log"))
+		if got := strings.Count(string(data), "\n"); got != 100 {
+			t.Errorf("Expected 100 lines for %s, got %d", tenant, got)
+		}
+	}
+}
+
+func TestPartitionHandler_LoggerName(t *testing.T) {
+	dir := t.TempDir()
+	h, err := NewPartitionHandler(PartitionConfig{
+		PathTemplate: filepath.Join(dir, "{logger}.log"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := logger.NewBuilder().WithHandler(h).WithName("api").Build()
+	api.Info("from api")
+	api.Named("db").With(logger.String("table", "users")).Info("from db")
+	logger.NewBuilder().WithHandler(h).Build().Info("unnamed")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"api": "from api", "api.db": "from db", "default": "unnamed"} {
+		if got, _ := os.ReadFile(filepath.Join(dir, name+".log")); !strings.Contains(string(got), want) {
+			t.Errorf("Expected %s.log to contain %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestPartitionHandler_SlowOpen(t *testing.T) {
+	release := make(chan struct{})
+	fsys := filehandler.NewMemFS()
+	fsys.Fault = func(op, name string) error {
+		if op == "open" && strings.HasSuffix(name, "slow.log") {
+			<-release
+		}
+		return nil
+	}
+	h, err := NewPartitionHandler(PartitionConfig{
+		PathTemplate: "{tenant}.log",
+		File:         filehandler.FileConfig{FS: fsys},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slow := make(chan error)
+	go func() {
+		slow <- h.Handle(&core.Entry{Level: core.InfoLevel, Message: "slow", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "slow"}}})
+	}()
+
+	// Other partitions are written while the slow one is still opening
+	done := make(chan error)
+	go func() {
+		done <- h.Handle(&core.Entry{Level: core.InfoLevel, Message: "fast", Fields: []core.Field{{Key: "tenant", Type: core.StringType, Str: "fast"}}})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a slow open not to block other partitions")
+	}
+
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParsePathTemplate(t *testing.T) {
+	for _, template := range []string{"app.log", "logs/{tenant", "logs/{}/app.log"} {
+		if _, err := NewPartitionHandler(PartitionConfig{PathTemplate: template}); err == nil {
+			t.Errorf("Expected error for template %q", template)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package partitionhandler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// pathTemplate is a compiled PathTemplate: literal text alternating with
+// field placeholders.
+type pathTemplate struct {
+	literals []string // len(literals) == len(keys)+1
+	keys     []string
+}
+
+// parsePathTemplate compiles a template such as "logs/{tenant}/app.log".
+func parsePathTemplate(template string) (*pathTemplate, error) {
+	t := &pathTemplate{}
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			t.literals = append(t.literals, rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("path template %q has an unterminated placeholder", template)
+		}
+		key := rest[start+1 : start+end]
+		if key == "" {
+			return nil, fmt.Errorf("path template %q has an empty placeholder", template)
+		}
+		t.literals = append(t.literals, rest[:start])
+		t.keys = append(t.keys, key)
+		rest = rest[start+end+1:]
+	}
+	if len(t.keys) == 0 {
+		return nil, fmt.Errorf("path template %q has no placeholder", template)
+	}
+	return t, nil
+}
+
+// expand returns the path for an entry with the given fields. Later
+// fields win, so call fields override logger fields.
+func (t *pathTemplate) expand(fallback string, fieldSets ...[]core.Field) string {
+	var sb strings.Builder
+	for i, key := range t.keys {
+		sb.WriteString(t.literals[i])
+		sb.WriteString(sanitize(lookup(key, fieldSets), fallback))
+	}
+	sb.WriteString(t.literals[len(t.keys)])
+	return sb.String()
+}
+
+// lookup returns the value of the last field named key, or "" if none.
+func lookup(key string, fieldSets [][]core.Field) string {
+	for s := len(fieldSets) - 1; s >= 0; s-- {
+		fields := fieldSets[s]
+		for i := len(fields) - 1; i >= 0; i-- {
+			if fields[i].Key == key {
+				return fields[i].StringValue()
+			}
+		}
+	}
+	return ""
+}
+
+// sanitize makes a field value safe as a single path element: characters
+// other than letters, digits, '-', '_' and '.' are replaced with '_', so a
+// value can never escape the directory chosen by the template.
+func sanitize(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	b := []byte(value)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			b[i] = '_'
+		}
+	}
+	if s := string(b); s != "." && s != ".." {
+		return s
+	}
+	return "_"
+}
@@ -67,6 +67,32 @@ type Stats struct {
 	BlockedTotal uint64
 	// ProcessedTotal counts total processed logs
 	ProcessedTotal uint64
+	// ErrorsTotal counts write, recover and fallback errors
+	ErrorsTotal uint64
+	// RetriesTotal counts write retries after an error
+	RetriesTotal uint64
+	// FallbackTotal counts entries written to the fallback handler
+	FallbackTotal uint64
+	// RecoveredTotal counts transitions from unhealthy back to healthy
+	RecoveredTotal uint64
+	// Unhealthy is 1 while the primary output is failing
+	Unhealthy uint32
+	// FlushTotal counts explicit buffer flushes
+	FlushTotal uint64
+	// FlushNanos is the cumulative flush latency in nanoseconds
+	FlushNanos uint64
+	// FlushMaxNanos is the largest single flush latency in nanoseconds
+	FlushMaxNanos uint64
+	// FsyncTotal counts fsync calls
+	FsyncTotal uint64
+	// FsyncNanos is the cumulative fsync latency in nanoseconds
+	FsyncNanos uint64
+	// FsyncMaxNanos is the largest single fsync latency in nanoseconds
+	FsyncMaxNanos uint64
+	// CompressedTotal counts rotated files compressed in the background
+	CompressedTotal uint64
+	// CompressErrorsTotal counts failed background compressions
+	CompressErrorsTotal uint64
 }
 
 // NewStats creates a new Stats instance
@@ -83,7 +109,8 @@ func (s *Stats) IncrementDropped(level core.Level) {
 		atomic.AddUint64(&s.DroppedInfo, 1)
 	case core.WarnLevel:
 		atomic.AddUint64(&s.DroppedWarn, 1)
-	case core.ErrorLevel:
+	case core.ErrorLevel, core.FatalLevel, core.PanicLevel:
+		// Fatal and Panic are counted as Error
 		atomic.AddUint64(&s.DroppedError, 1)
 	default:
 		panic("unhandled default case, Please create a issue in github.com/philipp01105/nlog")
@@ -105,6 +132,69 @@ func (s *Stats) AddProcessed(u uint64) {
 	atomic.AddUint64(&s.ProcessedTotal, u)
 }
 
+// IncrementErrors atomically increments the error counter
+func (s *Stats) IncrementErrors() {
+	atomic.AddUint64(&s.ErrorsTotal, 1)
+}
+
+// IncrementRetries atomically increments the retry counter
+func (s *Stats) IncrementRetries() {
+	atomic.AddUint64(&s.RetriesTotal, 1)
+}
+
+// IncrementFallback atomically increments the fallback counter
+func (s *Stats) IncrementFallback() {
+	atomic.AddUint64(&s.FallbackTotal, 1)
+}
+
+// IncrementRecovered atomically increments the recovered counter
+func (s *Stats) IncrementRecovered() {
+	atomic.AddUint64(&s.RecoveredTotal, 1)
+}
+
+// SetHealthy atomically records whether the primary output is working
+func (s *Stats) SetHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreUint32(&s.Unhealthy, 0)
+	} else {
+		atomic.StoreUint32(&s.Unhealthy, 1)
+	}
+}
+
+// ObserveFlush atomically records a flush that took d
+func (s *Stats) ObserveFlush(d time.Duration) {
+	atomic.AddUint64(&s.FlushTotal, 1)
+	atomic.AddUint64(&s.FlushNanos, uint64(d))
+	storeMax(&s.FlushMaxNanos, uint64(d))
+}
+
+// ObserveFsync atomically records an fsync that took d
+func (s *Stats) ObserveFsync(d time.Duration) {
+	atomic.AddUint64(&s.FsyncTotal, 1)
+	atomic.AddUint64(&s.FsyncNanos, uint64(d))
+	storeMax(&s.FsyncMaxNanos, uint64(d))
+}
+
+// storeMax atomically raises *addr to v if v is larger
+func storeMax(addr *uint64, v uint64) {
+	for {
+		cur := atomic.LoadUint64(addr)
+		if v <= cur || atomic.CompareAndSwapUint64(addr, cur, v) {
+			return
+		}
+	}
+}
+
+// IncrementCompressed atomically increments the compressed counter
+func (s *Stats) IncrementCompressed() {
+	atomic.AddUint64(&s.CompressedTotal, 1)
+}
+
+// IncrementCompressErrors atomically increments the compression error counter
+func (s *Stats) IncrementCompressErrors() {
+	atomic.AddUint64(&s.CompressErrorsTotal, 1)
+}
+
 // GetDropped returns the dropped count for a level
 func (s *Stats) GetDropped(level core.Level) uint64 {
 	switch level {
@@ -147,13 +237,38 @@ func (s *Stats) Reset() {
 	atomic.StoreUint64(&s.DroppedError, 0)
 	atomic.StoreUint64(&s.BlockedTotal, 0)
 	atomic.StoreUint64(&s.ProcessedTotal, 0)
+	atomic.StoreUint64(&s.ErrorsTotal, 0)
+	atomic.StoreUint64(&s.RetriesTotal, 0)
+	atomic.StoreUint64(&s.FallbackTotal, 0)
+	atomic.StoreUint64(&s.RecoveredTotal, 0)
+	atomic.StoreUint64(&s.FlushTotal, 0)
+	atomic.StoreUint64(&s.FlushNanos, 0)
+	atomic.StoreUint64(&s.FlushMaxNanos, 0)
+	atomic.StoreUint64(&s.FsyncTotal, 0)
+	atomic.StoreUint64(&s.FsyncNanos, 0)
+	atomic.StoreUint64(&s.FsyncMaxNanos, 0)
+	atomic.StoreUint64(&s.CompressedTotal, 0)
+	atomic.StoreUint64(&s.CompressErrorsTotal, 0)
 }
 
 // Snapshot returns a snapshot of current stats
 type Snapshot struct {
-	DroppedTotal   map[core.Level]uint64
-	BlockedTotal   uint64
-	ProcessedTotal uint64
+	DroppedTotal        map[core.Level]uint64
+	BlockedTotal        uint64
+	ProcessedTotal      uint64
+	ErrorsTotal         uint64
+	RetriesTotal        uint64
+	FallbackTotal       uint64
+	RecoveredTotal      uint64
+	Healthy             bool
+	FlushTotal          uint64
+	FlushTime           time.Duration // cumulative
+	FlushMax            time.Duration
+	FsyncTotal          uint64
+	FsyncTime           time.Duration // cumulative
+	FsyncMax            time.Duration
+	CompressedTotal     uint64
+	CompressErrorsTotal uint64
 }
 
 // GetSnapshot returns a snapshot of current statistics
@@ -165,7 +280,20 @@ func (s *Stats) GetSnapshot() Snapshot {
 			core.WarnLevel:  s.GetDropped(core.WarnLevel),
 			core.ErrorLevel: s.GetDropped(core.ErrorLevel),
 		},
-		BlockedTotal:   s.GetBlocked(),
-		ProcessedTotal: s.GetProcessed(),
+		BlockedTotal:        s.GetBlocked(),
+		ProcessedTotal:      s.GetProcessed(),
+		ErrorsTotal:         atomic.LoadUint64(&s.ErrorsTotal),
+		RetriesTotal:        atomic.LoadUint64(&s.RetriesTotal),
+		FallbackTotal:       atomic.LoadUint64(&s.FallbackTotal),
+		RecoveredTotal:      atomic.LoadUint64(&s.RecoveredTotal),
+		Healthy:             atomic.LoadUint32(&s.Unhealthy) == 0,
+		FlushTotal:          atomic.LoadUint64(&s.FlushTotal),
+		FlushTime:           time.Duration(atomic.LoadUint64(&s.FlushNanos)),
+		FlushMax:            time.Duration(atomic.LoadUint64(&s.FlushMaxNanos)),
+		FsyncTotal:          atomic.LoadUint64(&s.FsyncTotal),
+		FsyncTime:           time.Duration(atomic.LoadUint64(&s.FsyncNanos)),
+		FsyncMax:            time.Duration(atomic.LoadUint64(&s.FsyncMaxNanos)),
+		CompressedTotal:     atomic.LoadUint64(&s.CompressedTotal),
+		CompressErrorsTotal: atomic.LoadUint64(&s.CompressErrorsTotal),
 	}
 }
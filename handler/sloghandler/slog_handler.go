@@ -11,17 +11,20 @@ import (
 // SlogHandler is an adapter that implements slog.Handler using a logging-framework Handler.
 // This allows the logging framework to be used as a drop-in replacement for log/slog.
 type SlogHandler struct {
-	handler handler.Handler
-	level   core.Level
-	attrs   []core.Field
-	group   string
+	handler    handler.Handler
+	ctxHandler handler.ContextHandler // cached ContextHandler (nil when handler doesn't implement it)
+	level      core.Level
+	attrs      []core.Field
+	group      string
 }
 
 // NewSlogHandler creates a new slog.Handler adapter wrapping the given Handler.
 func NewSlogHandler(h handler.Handler, level core.Level) *SlogHandler {
+	ch, _ := h.(handler.ContextHandler)
 	return &SlogHandler{
-		handler: h,
-		level:   level,
+		handler:    h,
+		ctxHandler: ch,
+		level:      level,
 	}
 }
 
@@ -31,7 +34,8 @@ func (s *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
 }
 
 // Handle processes a slog.Record by converting it to a core.Entry and passing it to the wrapped handler.
-func (s *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+// The context is forwarded when the wrapped handler implements handler.ContextHandler.
+func (s *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
 	entry := core.GetEntry()
 	entry.Time = record.Time
 	entry.Level = slogLevelToCore(record.Level)
@@ -48,6 +52,9 @@ func (s *SlogHandler) Handle(_ context.Context, record slog.Record) error {
 		return true
 	})
 
+	if s.ctxHandler != nil {
+		return s.ctxHandler.HandleContext(ctx, entry)
+	}
 	return s.handler.Handle(entry)
 }
 
@@ -59,10 +66,11 @@ func (s *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		newAttrs = append(newAttrs, slogAttrToField(s.group, a))
 	}
 	return &SlogHandler{
-		handler: s.handler,
-		level:   s.level,
-		attrs:   newAttrs,
-		group:   s.group,
+		handler:    s.handler,
+		ctxHandler: s.ctxHandler,
+		level:      s.level,
+		attrs:      newAttrs,
+		group:      s.group,
 	}
 }
 
@@ -78,10 +86,11 @@ func (s *SlogHandler) WithGroup(name string) slog.Handler {
 	newAttrs := make([]core.Field, len(s.attrs))
 	copy(newAttrs, s.attrs)
 	return &SlogHandler{
-		handler: s.handler,
-		level:   s.level,
-		attrs:   newAttrs,
-		group:   newGroup,
+		handler:    s.handler,
+		ctxHandler: s.ctxHandler,
+		level:      s.level,
+		attrs:      newAttrs,
+		group:      newGroup,
 	}
 }
 
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+)
+
+// ErrorHandler is called with every error encountered by a handler's
+// background writer. It runs on the writer goroutine and must not block
+// for long or log through the failing handler.
+type ErrorHandler func(err error)
+
+// IsTransient reports whether a write error may succeed when retried.
+// Errors implementing Temporary() bool are asked directly; permission
+// and invalid-argument errors are permanent; everything else is treated
+// as transient.
+func IsTransient(err error) bool {
+	var t interface{ Temporary() bool }
+	if errors.As(err, &t) {
+		return t.Temporary()
+	}
+	if errors.Is(err, fs.ErrPermission) || errors.Is(err, fs.ErrInvalid) {
+		return false
+	}
+	return true
+}
+
+// SupervisorConfig configures a Supervisor.
+type SupervisorConfig struct {
+	// Write writes an entry to the primary output
+	Write func(entry *core.Entry) error
+	// Recover is called before every retry and recovery probe to restore
+	// the primary output, e.g. by reopening a file (optional)
+	Recover func() error
+	// Fallback receives entries while the primary output is failing
+	// (nil = entries are dropped)
+	Fallback Handler
+	// ErrorHandler is called for every write, recover and fallback error (optional)
+	ErrorHandler ErrorHandler
+	// MaxRetries is the number of retries for a transient error before
+	// the primary output is marked unhealthy
+	MaxRetries int
+	// RetryBackoff is the initial retry delay, doubled per attempt
+	RetryBackoff time.Duration
+	// MaxRetryBackoff caps the retry delay and the interval between
+	// recovery probes while unhealthy
+	MaxRetryBackoff time.Duration
+	// Stats receives error and health counters
+	Stats *Stats
+	// Closed aborts retry delays when the handler is shutting down
+	Closed <-chan struct{}
+	// Clock times the recovery probes while unhealthy (default:
+	// core.SystemClock). Retry delays use real time.
+	Clock core.Clock
+}
+
+// Supervisor guards the write path of an async handler's background
+// goroutine. A failed write is reported, retried with exponential backoff
+// and, if it keeps failing, the primary output is marked unhealthy: entries
+// are routed to the fallback handler while the primary is probed again at
+// increasing intervals until a write succeeds.
+//
+// A Supervisor is not safe for concurrent use; it is meant to be owned by
+// the single consumer goroutine.
+type Supervisor struct {
+	write            func(entry *core.Entry) error
+	recover          func() error
+	fallback         Handler
+	fallbackRecycles bool
+	onError          ErrorHandler
+	maxRetries       int
+	retryBackoff     time.Duration
+	maxRetryBackoff  time.Duration
+	stats            *Stats
+	closed           <-chan struct{}
+	clock            core.Clock
+	healthy          bool
+	probeBackoff     time.Duration
+	nextProbe        time.Time
+	timer            *time.Timer
+}
+
+// NewSupervisor creates a new Supervisor.
+func NewSupervisor(cfg SupervisorConfig) *Supervisor {
+	s := &Supervisor{
+		write:           cfg.Write,
+		recover:         cfg.Recover,
+		fallback:        cfg.Fallback,
+		onError:         cfg.ErrorHandler,
+		maxRetries:      cfg.MaxRetries,
+		retryBackoff:    cfg.RetryBackoff,
+		maxRetryBackoff: cfg.MaxRetryBackoff,
+		stats:           cfg.Stats,
+		closed:          cfg.Closed,
+		clock:           cfg.Clock,
+		healthy:         true,
+		probeBackoff:    cfg.RetryBackoff,
+		timer:           NewStoppedTimer(),
+	}
+	if s.clock == nil {
+		s.clock = core.SystemClock{}
+	}
+	if rc, ok := cfg.Fallback.(interface{ CanRecycleEntry() bool }); ok {
+		s.fallbackRecycles = rc.CanRecycleEntry()
+	}
+	return s
+}
+
+// Write writes entry to the primary output, retrying and falling back as
+// needed. It never fails; errors are reported through the ErrorHandler and
+// the Stats counters. The caller keeps ownership of entry.
+func (s *Supervisor) Write(entry *core.Entry) {
+	if !s.healthy {
+		s.probe(entry)
+		return
+	}
+
+	err := s.write(entry)
+	if err == nil {
+		return
+	}
+	s.report(err)
+
+	delay := s.retryBackoff
+	for i := 0; i < s.maxRetries && IsTransient(err); i++ {
+		if !s.sleep(delay) {
+			break // Shutting down, don't delay Close
+		}
+		s.stats.IncrementRetries()
+		if err = s.tryWrite(entry); err == nil {
+			return
+		}
+		delay = s.nextBackoff(delay)
+	}
+
+	s.healthy = false
+	s.stats.SetHealthy(false)
+	s.probeBackoff = s.retryBackoff
+	s.nextProbe = s.clock.Now().Add(s.probeBackoff)
+	s.toFallback(entry)
+}
+
+// Healthy reports whether the primary output is currently considered working.
+func (s *Supervisor) Healthy() bool {
+	return s.healthy
+}
+
+// probe routes entry to the fallback, or retries the primary output with
+// it once the probe interval has elapsed.
+func (s *Supervisor) probe(entry *core.Entry) {
+	now := s.clock.Now()
+	if now.Before(s.nextProbe) {
+		s.toFallback(entry)
+		return
+	}
+	if err := s.tryWrite(entry); err != nil {
+		s.probeBackoff = s.nextBackoff(s.probeBackoff)
+		s.nextProbe = now.Add(s.probeBackoff)
+		s.toFallback(entry)
+		return
+	}
+	s.healthy = true
+	s.stats.SetHealthy(true)
+	s.stats.IncrementRecovered()
+}
+
+// tryWrite recovers the primary output and writes entry, reporting errors.
+func (s *Supervisor) tryWrite(entry *core.Entry) error {
+	if s.recover != nil {
+		if err := s.recover(); err != nil {
+			s.report(err)
+			return err
+		}
+	}
+	err := s.write(entry)
+	if err != nil {
+		s.report(err)
+	}
+	return err
+}
+
+// toFallback hands entry to the fallback handler, or drops it.
+func (s *Supervisor) toFallback(entry *core.Entry) {
+	if s.fallback == nil {
+		s.stats.IncrementDropped(entry.Level)
+		return
+	}
+
+	e := entry
+	if !s.fallbackRecycles {
+		// The fallback keeps the entry after Handle returns, give it a copy
+		e = core.GetEntry()
+		e.Time = entry.Time
+		e.Level = entry.Level
+		e.Message = entry.Message
+		e.Caller = entry.Caller
+		e.Fields = append(e.Fields, entry.Fields...)
+	}
+
+	if err := s.fallback.Handle(e); err != nil {
+		s.report(err)
+		s.stats.IncrementDropped(entry.Level)
+		return
+	}
+	s.stats.IncrementFallback()
+}
+
+// report counts err and passes it to the ErrorHandler.
+func (s *Supervisor) report(err error) {
+	s.stats.IncrementErrors()
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// sleep waits for d, returning false if the handler is closed meanwhile.
+func (s *Supervisor) sleep(d time.Duration) bool {
+	s.timer.Reset(d)
+	select {
+	case <-s.timer.C:
+		return true
+	case <-s.closed:
+		s.timer.Stop()
+		return false
+	}
+}
+
+// nextBackoff doubles d, capped at maxRetryBackoff.
+func (s *Supervisor) nextBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > s.maxRetryBackoff {
+		d = s.maxRetryBackoff
+	}
+	return d
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/philipp01105/nlog/core"
+	"github.com/philipp01105/nlog/nlogtest"
+)
+
+// recordingHandler collects the messages of handled entries.
+type recordingHandler struct {
+	messages []string
+}
+
+func (r *recordingHandler) Handle(entry *core.Entry) error {
+	r.messages = append(r.messages, entry.Message)
+	return nil
+}
+
+func (r *recordingHandler) Close() error { return nil }
+
+func (r *recordingHandler) CanRecycleEntry() bool { return true }
+
+func TestSupervisor_RetrySucceeds(t *testing.T) {
+	stats := NewStats()
+	failures := 2
+	var reported []error
+	s := NewSupervisor(SupervisorConfig{
+		Write: func(entry *core.Entry) error {
+			if failures > 0 {
+				failures--
+				return errors.New("transient")
+			}
+			return nil
+		},
+		ErrorHandler:    func(err error) { reported = append(reported, err) },
+		MaxRetries:      3,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+		Stats:           stats,
+	})
+
+	s.Write(&core.Entry{Message: "msg"})
+
+	snap := stats.GetSnapshot()
+	if !snap.Healthy || !s.Healthy() {
+		t.Error("Expected supervisor to stay healthy after a successful retry")
+	}
+	if snap.ErrorsTotal != 2 || len(reported) != 2 {
+		t.Errorf("Expected 2 reported errors, got %d (handler saw %d)", snap.ErrorsTotal, len(reported))
+	}
+	if snap.RetriesTotal != 2 {
+		t.Errorf("Expected 2 retries, got %d", snap.RetriesTotal)
+	}
+}
+
+func TestSupervisor_FallbackAndRecovery(t *testing.T) {
+	stats := NewStats()
+	fallback := &recordingHandler{}
+	failing := true
+	recovers := 0
+	s := NewSupervisor(SupervisorConfig{
+		Write: func(entry *core.Entry) error {
+			if failing {
+				return errors.New("disk gone")
+			}
+			return nil
+		},
+		Recover: func() error {
+			recovers++
+			return nil
+		},
+		Fallback:        fallback,
+		MaxRetries:      1,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: 5 * time.Millisecond,
+		Stats:           stats,
+	})
+
+	s.Write(&core.Entry{Message: "first"})
+	s.Write(&core.Entry{Message: "second"}) // Within probe interval: straight to fallback
+	if s.Healthy() || stats.GetSnapshot().Healthy {
+		t.Fatal("Expected supervisor to be unhealthy")
+	}
+	if len(fallback.messages) != 2 {
+		t.Fatalf("Expected 2 fallback entries, got %v", fallback.messages)
+	}
+
+	failing = false
+	time.Sleep(10 * time.Millisecond)
+	s.Write(&core.Entry{Message: "third"})
+
+	snap := stats.GetSnapshot()
+	if !snap.Healthy {
+		t.Error("Expected supervisor to recover")
+	}
+	if snap.RecoveredTotal != 1 {
+		t.Errorf("Expected 1 recovery, got %d", snap.RecoveredTotal)
+	}
+	if snap.FallbackTotal != 2 {
+		t.Errorf("Expected 2 fallback writes, got %d", snap.FallbackTotal)
+	}
+	if recovers == 0 {
+		t.Error("Expected Recover to be called before retrying")
+	}
+}
+
+func TestSupervisor_ProbeUsesClock(t *testing.T) {
+	clock := nlogtest.NewManualClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	fallback := &recordingHandler{}
+	failing := true
+	s := NewSupervisor(SupervisorConfig{
+		Write: func(entry *core.Entry) error {
+			if failing {
+				return fs.ErrPermission
+			}
+			return nil
+		},
+		Fallback:        fallback,
+		RetryBackoff:    time.Minute,
+		MaxRetryBackoff: time.Hour,
+		Stats:           NewStats(),
+		Clock:           clock,
+	})
+
+	s.Write(&core.Entry{Message: "first"})
+	failing = false
+	clock.Advance(59 * time.Second)
+	s.Write(&core.Entry{Message: "second"}) // Probe not due yet
+	if s.Healthy() || len(fallback.messages) != 2 {
+		t.Fatalf("Expected both entries in the fallback, got %v", fallback.messages)
+	}
+
+	clock.Advance(time.Second)
+	s.Write(&core.Entry{Message: "third"})
+	if !s.Healthy() || len(fallback.messages) != 2 {
+		t.Errorf("Expected the probe to recover once the clock reached it, got %v", fallback.messages)
+	}
+}
+
+func TestSupervisor_NoFallbackDrops(t *testing.T) {
+	stats := NewStats()
+	s := NewSupervisor(SupervisorConfig{
+		Write:           func(entry *core.Entry) error { return fs.ErrPermission },
+		MaxRetries:      3,
+		RetryBackoff:    time.Second,
+		MaxRetryBackoff: time.Second,
+		Stats:           stats,
+	})
+
+	start := time.Now()
+	s.Write(&core.Entry{Level: core.ErrorLevel})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Permanent error should not be retried, took %v", elapsed)
+	}
+	if got := stats.GetDropped(core.ErrorLevel); got != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", got)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	if !IsTransient(errors.New("short write")) {
+		t.Error("Expected plain errors to be transient")
+	}
+	if IsTransient(&fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission}) {
+		t.Error("Expected permission errors to be permanent")
+	}
+}
@@ -0,0 +1,128 @@
+// Package jsonstr escapes strings for the JSON written by hand in the
+// formatters and handlers, so they all agree on the output.
+package jsonstr
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Flags select additional escaping. Invalid UTF-8 is always replaced with
+// U+FFFD. C1 controls (U+0080-U+009F), which terminals interpret, and
+// U+2028 and U+2029, which end lines in JavaScript, are always escaped.
+type Flags uint8
+
+const (
+	// HTML escapes <, > and & as \u003c, \u003e and \u0026
+	HTML Flags = 1 << iota
+	// ASCII escapes every non-ASCII character as \uXXXX, using surrogate
+	// pairs above U+FFFF
+	ASCII
+)
+
+// safe reports which bytes are written as is in every mode. <, > and &
+// are left out so HTML mode is only checked when one of them is found;
+// non-ASCII bytes need a UTF-8 check.
+var safe = func() (safe [256]bool) {
+	for c := 0x20; c < utf8.RuneSelf; c++ {
+		switch c {
+		case '"', '\\', 0x7f, '<', '>', '&':
+		default:
+			safe[c] = true
+		}
+	}
+	return safe
+}()
+
+const hex = "0123456789abcdef"
+
+// Append writes s JSON-escaped, without surrounding quotes, to buf
+func Append(buf *bytes.Buffer, s string, flags Flags) {
+	// Fast path: one table lookup per byte until one needs a look
+	for i := 0; i < len(s); i++ {
+		if !safe[s[i]] {
+			appendFrom(buf, s, i, flags)
+			return
+		}
+	}
+	buf.WriteString(s)
+}
+
+// appendFrom is Append for s whose first byte needing a look is at i
+func appendFrom(buf *bytes.Buffer, s string, i int, flags Flags) {
+	start := 0
+	for i < len(s) {
+		c := s[i]
+		if safe[c] {
+			i++
+			continue
+		}
+		if c < utf8.RuneSelf {
+			if flags&HTML == 0 && (c == '<' || c == '>' || c == '&') {
+				i++
+				continue
+			}
+			// Flush unescaped prefix
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"':
+				buf.WriteString(`\"`)
+			case '\\':
+				buf.WriteString(`\\`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[c>>4])
+				buf.WriteByte(hex[c&0x0f])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\ufffd`)
+			i++
+			start = i
+			continue
+		}
+		if r < 0xa0 || r == '\u2028' || r == '\u2029' || flags&ASCII != 0 {
+			buf.WriteString(s[start:i])
+			AppendRune(buf, r)
+			start = i + size
+		}
+		i += size
+	}
+	// Flush remaining
+	buf.WriteString(s[start:])
+}
+
+// AppendQuoted writes s as a quoted JSON string to buf
+func AppendQuoted(buf *bytes.Buffer, s string, flags Flags) {
+	buf.WriteByte('"')
+	Append(buf, s, flags)
+	buf.WriteByte('"')
+}
+
+// AppendRune writes r as \uXXXX, or as a surrogate pair above U+FFFF
+func AppendRune(buf *bytes.Buffer, r rune) {
+	if r > 0xffff {
+		hi, lo := utf16.EncodeRune(r)
+		AppendRune(buf, hi)
+		AppendRune(buf, lo)
+		return
+	}
+	buf.WriteString(`\u`)
+	buf.WriteByte(hex[r>>12&0x0f])
+	buf.WriteByte(hex[r>>8&0x0f])
+	buf.WriteByte(hex[r>>4&0x0f])
+	buf.WriteByte(hex[r&0x0f])
+}
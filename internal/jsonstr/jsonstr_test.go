@@ -0,0 +1,39 @@
+package jsonstr
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendQuoted(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		flags Flags
+		want  string
+	}{
+		{"Plain", "hello", 0, `"hello"`},
+		{"Escapes", "a\"b\\c\nd\te\x01\x7f", 0, `"a\"b\\c\nd\te\u0001\u007f"`},
+		{"InvalidUTF8", "a\xffb", 0, `"a\ufffdb"`},
+		{"LineSeparator", "a\u2028b", 0, `"a\u2028b"`},
+		{"C1", "csi\u009b1m nbsp\u00a0", 0, "\"csi\\u009b1m nbsp\u00a0\""},
+		{"HTML", "<a&b>", HTML, `"\u003ca\u0026b\u003e"`},
+		{"NoHTML", "<a&b>", 0, `"<a&b>"`},
+		{"ASCII", "\u00e9\U0001F600", ASCII, `"\u00e9\ud83d\ude00"`},
+		{"UTF8", "\u00e9\U0001F600", 0, "\"\u00e9\U0001F600\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			AppendQuoted(&buf, tt.in, tt.flags)
+			if buf.String() != tt.want {
+				t.Errorf("AppendQuoted(%q) = %s, want %s", tt.in, buf.String(), tt.want)
+			}
+			var got string
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Errorf("Output %s is not valid JSON: %v", buf.String(), err)
+			}
+		})
+	}
+}
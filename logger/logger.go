@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/philipp01105/nlog/core"
@@ -16,24 +17,45 @@ var osExit = os.Exit
 type Logger struct {
 	handler       handler.Handler
 	fastHandler   handler.FastHandler
+	fieldsHandler handler.LoggerFieldsHandler
 	level         core.Level
 	fields        []core.Field
+	builderFields int // leading fields given to Builder.WithFields
 	includeCaller bool
 	callerSkip    int
 	recycleEntry  bool
-	coarseClock   bool
+	clock         core.Clock
+	clockRef      *clockRef
+	name          string
+}
+
+// clockRef is the CoarseClock reference taken by Build. It is shared with
+// loggers derived by With and released on the first Close.
+type clockRef struct {
+	clock *core.CoarseClock
+	once  sync.Once
+}
+
+// release stops the clock reference once
+func (r *clockRef) release() {
+	if r != nil {
+		r.once.Do(r.clock.Stop)
+	}
 }
 
 // Builder provides a fluent API for building Logger instances
 type Builder struct {
 	handler       handler.Handler
 	fastHandler   handler.FastHandler
+	fieldsHandler handler.LoggerFieldsHandler
 	level         core.Level
 	fields        []core.Field
 	includeCaller bool
 	callerSkip    int
 	recycleEntry  bool
 	coarseClock   bool
+	clock         core.Clock
+	name          string
 }
 
 // NewBuilder creates a new logger builder
@@ -55,6 +77,7 @@ func (b *Builder) WithHandler(h handler.Handler) *Builder {
 	}
 	// Cache FastHandler for pool-free hot path
 	b.fastHandler, _ = h.(handler.FastHandler)
+	b.fieldsHandler, _ = h.(handler.LoggerFieldsHandler)
 	return b
 }
 
@@ -70,6 +93,13 @@ func (b *Builder) WithFields(fields ...core.Field) *Builder {
 	return b
 }
 
+// WithName names the logger. The name is attached to every entry as the
+// core.LoggerNameKey field, so handlers can route or filter by it.
+func (b *Builder) WithName(name string) *Builder {
+	b.name = name
+	return b
+}
+
 // WithCaller enables caller information
 func (b *Builder) WithCaller(enabled bool) *Builder {
 	b.includeCaller = enabled
@@ -79,27 +109,51 @@ func (b *Builder) WithCaller(enabled bool) *Builder {
 // WithCoarseClock enables a cached timestamp that is updated every 500µs
 // by a background goroutine instead of calling time.Now() on every log
 // call. This reduces per-call overhead at the cost of up to ~0.5ms
-// timestamp granularity. The background goroutine is started once and
-// runs for the lifetime of the process.
+// timestamp granularity. All such loggers share core.DefaultCoarseClock;
+// its goroutine runs until every one of them is closed.
 func (b *Builder) WithCoarseClock(enabled bool) *Builder {
 	b.coarseClock = enabled
 	return b
 }
 
+// WithClock sets the clock that timestamps entries, e.g. a manual clock
+// in tests. It takes precedence over WithCoarseClock. A *core.CoarseClock
+// is started by Build and stopped by Close.
+func (b *Builder) WithClock(clock core.Clock) *Builder {
+	b.clock = clock
+	return b
+}
+
 // Build creates the Logger instance
 func (b *Builder) Build() *Logger {
-	if b.coarseClock {
-		core.StartCoarseClock()
+	clock := b.clock
+	if clock == nil && b.coarseClock {
+		clock = core.DefaultCoarseClock()
+	}
+	var ref *clockRef
+	if coarse, ok := clock.(*core.CoarseClock); ok {
+		coarse.Start()
+		ref = &clockRef{clock: coarse}
+	}
+	fields := b.fields
+	if b.name != "" {
+		// The name follows the builder fields, so Named can replace it in
+		// place and HandleLoggerFields can pass the builder fields alone
+		fields = append(fields[:len(fields):len(fields)], nameField(b.name))
 	}
 	return &Logger{
 		handler:       b.handler,
 		fastHandler:   b.fastHandler,
+		fieldsHandler: b.fieldsHandler,
 		level:         b.level,
-		fields:        b.fields,
+		fields:        fields,
+		builderFields: len(b.fields),
 		includeCaller: b.includeCaller,
 		callerSkip:    b.callerSkip,
 		recycleEntry:  b.recycleEntry,
-		coarseClock:   b.coarseClock,
+		clock:         clock,
+		clockRef:      ref,
+		name:          b.name,
 	}
 }
 
@@ -112,15 +166,61 @@ func (l *Logger) With(fields ...core.Field) *Logger {
 	return &Logger{
 		handler:       l.handler,
 		fastHandler:   l.fastHandler,
+		fieldsHandler: l.fieldsHandler,
+		level:         l.level,
+		fields:        newFields,
+		builderFields: l.builderFields,
+		includeCaller: l.includeCaller,
+		callerSkip:    l.callerSkip,
+		recycleEntry:  l.recycleEntry,
+		clock:         l.clock,
+		clockRef:      l.clockRef,
+		name:          l.name,
+	}
+}
+
+// Named creates a new Logger whose name is the current name and name
+// joined by a dot, e.g. "api.db" (immutable operation)
+func (l *Logger) Named(name string) *Logger {
+	var newFields []core.Field
+	at := l.builderFields
+	if l.name == "" {
+		newFields = make([]core.Field, len(l.fields)+1)
+		copy(newFields, l.fields[:at])
+		copy(newFields[at+1:], l.fields[at:])
+	} else {
+		name = l.name + "." + name
+		newFields = make([]core.Field, len(l.fields))
+		copy(newFields, l.fields)
+	}
+	newFields[at] = nameField(name)
+
+	return &Logger{
+		handler:       l.handler,
+		fastHandler:   l.fastHandler,
+		fieldsHandler: l.fieldsHandler,
 		level:         l.level,
 		fields:        newFields,
+		builderFields: l.builderFields,
 		includeCaller: l.includeCaller,
 		callerSkip:    l.callerSkip,
 		recycleEntry:  l.recycleEntry,
-		coarseClock:   l.coarseClock,
+		clock:         l.clock,
+		clockRef:      l.clockRef,
+		name:          name,
 	}
 }
 
+// Name returns the logger's name, or "" if it has none
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// nameField returns the field carrying a logger name
+func nameField(name string) core.Field {
+	return core.Field{Key: core.LoggerNameKey, Type: core.StringType, Str: name}
+}
+
 // Log logs a message at the specified level
 func (l *Logger) Log(level core.Level, msg string, fields ...core.Field) {
 	// Level check optimization - exit early BEFORE any allocations
@@ -141,29 +241,22 @@ func (l *Logger) log(level core.Level, msg string, fields []core.Field) {
 	// Fast path: use FastHandler when there are no call-site fields.
 	// This avoids sync.Pool Get/Put overhead. We cannot pass variadic
 	// fields through the interface because that causes them to escape
-	// to the heap.
+	// to the heap. The builder fields are passed as loggerFields and the
+	// rest of the logger's fields as callFields, like LoggerFieldsHandler
+	// tells them apart.
 	if l.fastHandler != nil && len(fields) == 0 {
-		var t time.Time
-		if l.coarseClock {
-			t = core.CoarseNow()
-		} else {
-			t = time.Now()
-		}
+		t := l.now()
 		var caller core.CallerInfo
 		if l.includeCaller {
 			caller = core.GetCaller(l.callerSkip)
 		}
-		l.fastHandler.HandleLog(t, level, msg, l.fields, nil, caller)
+		l.fastHandler.HandleLog(t, level, msg, l.fields[:l.builderFields], l.fields[l.builderFields:], caller)
 		return
 	}
 
 	// Get entry from pool AFTER level check
 	entry := core.GetEntry()
-	if l.coarseClock {
-		entry.Time = core.CoarseNow()
-	} else {
-		entry.Time = time.Now()
-	}
+	entry.Time = l.now()
 	entry.Level = level
 	entry.Message = msg
 
@@ -181,7 +274,12 @@ func (l *Logger) log(level core.Level, msg string, fields []core.Field) {
 		entry.Caller = core.GetCaller(l.callerSkip)
 	}
 
-	err := l.handler.Handle(entry)
+	var err error
+	if l.fieldsHandler != nil {
+		err = l.fieldsHandler.HandleLoggerFields(entry, l.builderFields)
+	} else {
+		err = l.handler.Handle(entry)
+	}
 	if err != nil {
 		return
 	}
@@ -192,6 +290,14 @@ func (l *Logger) log(level core.Level, msg string, fields []core.Field) {
 	}
 }
 
+// now returns the timestamp for a new entry.
+func (l *Logger) now() time.Time {
+	if l.clock != nil {
+		return l.clock.Now()
+	}
+	return time.Now()
+}
+
 // Debug logs a debug message
 func (l *Logger) Debug(msg string, fields ...core.Field) {
 	if core.DebugLevel < l.level {
@@ -281,8 +387,9 @@ func (l *Logger) Panicf(format string, args ...interface{}) {
 	panic(msg)
 }
 
-// Close closes the logger's handler
+// Close releases the logger's coarse clock and closes its handler
 func (l *Logger) Close() error {
+	l.clockRef.release()
 	if l.handler != nil {
 		return l.handler.Close()
 	}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/philipp01105/nlog/core"
 	"github.com/philipp01105/nlog/formatter"
 	"github.com/philipp01105/nlog/handler/consolehandler"
+	"github.com/philipp01105/nlog/nlogtest"
 )
 
 func TestLogger_LevelGate(t *testing.T) {
@@ -138,6 +141,47 @@ func TestLogger_FormattedLogging(t *testing.T) {
 	}
 }
 
+func TestLogger_Named(t *testing.T) {
+	var buf bytes.Buffer
+	h := consolehandler.NewConsoleHandler(consolehandler.ConsoleConfig{
+		Writer:    &buf,
+		Async:     false,
+		Formatter: formatter.NewTextFormatter(formatter.Config{}),
+	})
+
+	parent := NewBuilder().
+		WithHandler(h).
+		WithName("api").
+		WithFields(String("app", "test")).
+		Build()
+	child := parent.Named("db")
+
+	if parent.Name() != "api" || child.Name() != "api.db" {
+		t.Errorf("Expected names api and api.db, got %q and %q", parent.Name(), child.Name())
+	}
+
+	child.Info("child message")
+	output := buf.String()
+	if !strings.Contains(output, "logger=api.db") || strings.Count(output, "logger=") != 1 {
+		t.Errorf("Expected a single logger=api.db field, got: %s", output)
+	}
+	if !strings.Contains(output, "app=test") {
+		t.Errorf("Expected parent fields in child output, got: %s", output)
+	}
+
+	buf.Reset()
+	parent.Info("parent message")
+	if !strings.Contains(buf.String(), "logger=api") || strings.Contains(buf.String(), "api.db") {
+		t.Errorf("Expected parent name to be unchanged, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	NewBuilder().WithHandler(h).Build().Named("worker").Info("unnamed parent")
+	if !strings.Contains(buf.String(), "logger=worker") {
+		t.Errorf("Expected logger=worker, got: %s", buf.String())
+	}
+}
+
 func TestLogger_ImmutableWith(t *testing.T) {
 	var buf bytes.Buffer
 	h := consolehandler.NewConsoleHandler(consolehandler.ConsoleConfig{
@@ -356,6 +400,59 @@ func TestLogger_CoarseClockWith(t *testing.T) {
 	}
 }
 
+func TestLogger_CoarseClockRelease(t *testing.T) {
+	clock := core.NewCoarseClock(core.CoarseClockConfig{})
+	parent := NewBuilder().WithClock(clock).Build()
+	other := NewBuilder().WithClock(clock).Build()
+	child := parent.With(String("child", "value"))
+	if !clock.Running() {
+		t.Fatal("Expected Build to start the coarse clock")
+	}
+
+	// Closing a logger and its derived loggers releases one reference
+	child.Close()
+	parent.Close()
+	if !clock.Running() {
+		t.Error("Expected clock to keep running for the other logger")
+	}
+	other.Close()
+	if clock.Running() {
+		t.Error("Expected clock to stop once all loggers are closed")
+	}
+}
+
+func TestLogger_WithClock(t *testing.T) {
+	var buf bytes.Buffer
+	h := consolehandler.NewConsoleHandler(consolehandler.ConsoleConfig{
+		Writer:    &buf,
+		Async:     false,
+		Formatter: formatter.NewTextFormatter(formatter.Config{}),
+	})
+
+	clock := nlogtest.NewManualClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	log := NewBuilder().
+		WithHandler(h).
+		WithLevel(InfoLevel).
+		WithCoarseClock(true). // The explicit clock wins
+		WithClock(clock).
+		Build()
+
+	log.Info("fast path")
+	clock.Advance(time.Hour)
+	log.With(String("child", "value")).Info("with field", String("key", "value"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "2024-01-02T03:04:05Z") {
+		t.Errorf("Expected manual clock time in %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "2024-01-02T04:04:05Z") {
+		t.Errorf("Expected advanced clock time in %q", lines[1])
+	}
+}
+
 func TestParseLevel_FatalPanic(t *testing.T) {
 	if ParseLevel("FATAL") != FatalLevel {
 		t.Error("Expected FatalLevel for 'FATAL'")
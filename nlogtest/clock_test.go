@@ -0,0 +1,25 @@
+package nlogtest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("Now() = %v, want %v", got, start)
+	}
+	if got := clock.Advance(time.Minute); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("Advance() = %v, want %v", got, start.Add(time.Minute))
+	}
+	if got := clock.Now(); !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("Now() after Advance = %v, want %v", got, start.Add(time.Minute))
+	}
+	clock.Set(start)
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("Now() after Set = %v, want %v", got, start)
+	}
+}
@@ -0,0 +1,15 @@
+// Package nlogtest provides helpers for testing code that logs with NLog.
+//
+// ManualClock is a core.Clock that only moves when told to. Pass it to
+// logger.Builder.WithClock for fixed timestamps, or to
+// filehandler.FileConfig.Clock to trigger time-based rotation and
+// retention without sleeping:
+//
+//	clock := nlogtest.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	h, _ := filehandler.NewFileHandler(filehandler.FileConfig{
+//	    Filename:       "app.log",
+//	    RotateInterval: time.Hour,
+//	    Clock:          clock,
+//	})
+//	clock.Advance(time.Hour) // the next entry rotates
+package nlogtest